@@ -0,0 +1,443 @@
+package httpdoc
+
+import (
+	"bytes"
+	"math"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/goldic/js"
+	"golang.org/x/net/html"
+)
+
+// Article is main content of html-document (see Document.Article)
+type Article struct {
+	Title     string
+	Byline    string
+	Published time.Time
+	Image     string // absolute url of lead image
+	HTML      string // cleaned html of main content
+	Text      string // plain text of main content
+}
+
+var (
+	reArticleUnlikely = regexp.MustCompile(`(?i)-ad-|ai2html|banner|breadcrumb|combx|comment|community|consent|cookie|cover-wrap|disqus|extra|footer|gdpr|header|legends|menu|newsletter|related|remark|replies|rss|share|shoutbox|sidebar|skyscraper|social|sponsor|subscribe|supplemental|ad-break|agegate|pagination|pager|popup`)
+	reArticleMaybe    = regexp.MustCompile(`(?i)and|article|body|column|content|main|shadow`)
+	reArticlePositive = regexp.MustCompile(`(?i)article|body|content|entry|hentry|h-entry|main|page|post|text|blog|story`)
+	reArticleNegative = regexp.MustCompile(`(?i)-ad-|hidden|banner|combx|comment|com-|contact|foot|footer|footnote|gdpr|masthead|media|meta|outbrain|promo|related|scroll|share|shoutbox|sidebar|skyscraper|sponsor|shopping|tags|tool|widget|cookie|consent`)
+	reArticleByline   = regexp.MustCompile(`(?i)byline|author|dateline|writtenby|p-author`)
+	reTitleSeparator  = regexp.MustCompile(`\s+(?:[|\-–—/>»]|::)\s+`)
+)
+
+// articleRemovedTags are tags which never belong to main content
+var articleRemovedTags = map[string]bool{
+	"script": true, "style": true, "noscript": true, "template": true, "iframe": true, "nav": true, "aside": true,
+	"footer": true, "form": true, "button": true, "input": true, "select": true, "textarea": true, "svg": true,
+	"canvas": true, "object": true, "embed": true, "link": true, "meta": true, "dialog": true,
+}
+
+var articleBlockTags = map[string]bool{
+	"blockquote": true, "dl": true, "div": true, "img": true, "ol": true, "p": true, "pre": true,
+	"table": true, "ul": true, "section": true, "article": true, "figure": true, "h1": true, "h2": true, "h3": true,
+}
+
+var articleKeptAttrs = map[string]bool{
+	"href": true, "src": true, "alt": true, "title": true, "datetime": true, "colspan": true, "rowspan": true,
+}
+
+// Article identifies main content block of html-document (like Mozilla Readability): scores paragraphs by text length,
+// commas, link density and class/id heuristics. Navigation, sidebars, comments, cookie banners etc are removed.
+func (d *Document) Article() *Article {
+	meta := d.Metadata()
+	base := d.BaseURL()
+	a := &Article{}
+	root, err := html.Parse(strings.NewReader(d.ContentStr())) // own tree: it is modified
+	if err != nil {
+		return a
+	}
+	ld := jsonLDArticle(meta.JSONLD)
+
+	a.Title = articleTitle(root, meta, ld)
+	a.Byline = articleByline(meta, ld)
+	a.Published = articlePublished(root, meta, ld)
+	a.Image = meta.OpenGraph.Get("og:image")
+	if a.Image == "" {
+		a.Image = meta.Twitter.Get("twitter:image")
+	}
+	if a.Image == "" {
+		a.Image = resolveURL(base, jsonLDString(ld["image"]))
+	}
+
+	body := findHTMLNode(root, "body")
+	if body == nil {
+		return a
+	}
+	if byline := prepareArticle(body); a.Byline == "" {
+		a.Byline = byline
+	}
+	nodes := articleContent(body)
+
+	var buf bytes.Buffer
+	for _, n := range nodes {
+		cleanArticle(n, a.Title, base)
+		html.Render(&buf, n)
+	}
+	if a.Image == "" {
+		for _, n := range nodes {
+			if img := findHTMLNode(n, "img"); img != nil {
+				a.Image = htmlAttr(img, "src")
+				break
+			}
+		}
+	}
+	a.HTML = buf.String()
+	a.Text = HtmlToText(a.HTML)
+	return a
+}
+
+// prepareArticle removes hidden, unlikely and non-content elements; returns byline if found
+func prepareArticle(body *html.Node) (byline string) {
+	var removed []*html.Node
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			switch c.Type {
+			case html.CommentNode:
+				removed = append(removed, c)
+				continue
+			case html.ElementNode:
+			default:
+				continue
+			}
+			classID := htmlAttr(c, "class") + " " + htmlAttr(c, "id")
+			if articleRemovedTags[c.Data] || isHiddenHTMLNode(c) {
+				removed = append(removed, c)
+				continue
+			}
+			if byline == "" && (reArticleByline.MatchString(classID) || htmlAttr(c, "rel") == "author" || htmlAttr(c, "itemprop") == "author") {
+				if text := htmlNodeText(c); text != "" && utf8.RuneCountInString(text) < 100 {
+					byline = text
+					removed = append(removed, c)
+					continue
+				}
+			}
+			if c.Data != "body" && c.Data != "article" && c.Data != "main" &&
+				reArticleUnlikely.MatchString(classID) && !reArticleMaybe.MatchString(classID) && findHTMLNode(c, "article") == nil {
+				removed = append(removed, c)
+				continue
+			}
+			walk(c)
+		}
+	}
+	walk(body)
+	for _, n := range removed {
+		n.Parent.RemoveChild(n)
+	}
+	return
+}
+
+func isHiddenHTMLNode(n *html.Node) bool {
+	style := strings.ReplaceAll(strings.ToLower(htmlAttr(n, "style")), " ", "")
+	return hasHTMLAttr(n, "hidden") || htmlAttr(n, "aria-hidden") == "true" ||
+		strings.Contains(style, "display:none") || strings.Contains(style, "visibility:hidden")
+}
+
+// articleContent returns top-scored candidate node and its related siblings
+func articleContent(body *html.Node) []*html.Node {
+	scores := map[*html.Node]float64{}
+	walkHTML(body, func(n *html.Node) {
+		if n.Data != "p" && n.Data != "pre" && n.Data != "td" && !(n.Data == "div" && !hasBlockChildren(n)) {
+			return
+		}
+		text := htmlNodeText(n)
+		textLen := utf8.RuneCountInString(text)
+		if textLen < 25 {
+			return
+		}
+		score := 1 + float64(strings.Count(text, ",")) + math.Min(float64(textLen/100), 3)
+		for level, anc := 0, n.Parent; anc != nil && anc != body.Parent && level < 3; level, anc = level+1, anc.Parent {
+			if _, ok := scores[anc]; !ok {
+				scores[anc] = articleNodeWeight(anc)
+			}
+			switch level {
+			case 0:
+				scores[anc] += score
+			case 1:
+				scores[anc] += score / 2
+			default:
+				scores[anc] += score / float64(level*3)
+			}
+		}
+	})
+	var top *html.Node
+	for n, score := range scores {
+		score *= 1 - linkDensity(n)
+		scores[n] = score
+		if top == nil || score > scores[top] {
+			top = n
+		}
+	}
+	if top == nil || top == body {
+		return []*html.Node{body}
+	}
+	// append siblings with related content
+	threshold := math.Max(10, scores[top]*0.2)
+	var nodes []*html.Node
+	for s := top.Parent.FirstChild; s != nil; s = s.NextSibling {
+		if s.Type != html.ElementNode {
+			continue
+		}
+		score, scored := scores[s]
+		ok := s == top || scored && score >= threshold
+		if !ok && s.Data == "p" {
+			text := htmlNodeText(s)
+			textLen, density := utf8.RuneCountInString(text), linkDensity(s)
+			ok = textLen > 80 && density < 0.25 || textLen > 0 && density == 0 && strings.HasSuffix(text, ".")
+		}
+		if ok {
+			nodes = append(nodes, s)
+		}
+	}
+	return nodes
+}
+
+func hasBlockChildren(n *html.Node) bool {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && (articleBlockTags[c.Data] || hasBlockChildren(c)) {
+			return true
+		}
+	}
+	return false
+}
+
+func articleNodeWeight(n *html.Node) (w float64) {
+	switch n.Data {
+	case "div", "article", "main":
+		w = 5
+	case "pre", "td", "blockquote":
+		w = 3
+	case "address", "ol", "ul", "dl", "dd", "dt", "li", "form":
+		w = -3
+	case "h1", "h2", "h3", "h4", "h5", "h6", "th":
+		w = -5
+	}
+	return w + articleClassWeight(n)
+}
+
+func articleClassWeight(n *html.Node) (w float64) {
+	for _, s := range []string{htmlAttr(n, "class"), htmlAttr(n, "id")} {
+		if s == "" {
+			continue
+		}
+		if reArticleNegative.MatchString(s) {
+			w -= 25
+		}
+		if reArticlePositive.MatchString(s) {
+			w += 25
+		}
+	}
+	return
+}
+
+// linkDensity returns ratio of link text length to text length of node
+func linkDensity(n *html.Node) float64 {
+	textLen := utf8.RuneCountInString(htmlNodeText(n))
+	if textLen == 0 {
+		return 0
+	}
+	var linksLen int
+	walkHTML(n, func(c *html.Node) {
+		if c.Data == "a" && !hasHTMLAttrAncestor(c, n, "a") {
+			linksLen += utf8.RuneCountInString(htmlNodeText(c))
+		}
+	})
+	return float64(linksLen) / float64(textLen)
+}
+
+func hasHTMLAttrAncestor(n, stop *html.Node, tag string) bool {
+	for p := n.Parent; p != nil && p != stop; p = p.Parent {
+		if p.Data == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// cleanArticle removes link-lists, empty and duplicated elements, presentational attributes; makes urls absolute
+func cleanArticle(root *html.Node, title string, base *url.URL) {
+	var removed []*html.Node
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			text := htmlNodeText(c)
+			textLen := utf8.RuneCountInString(text)
+			switch c.Data {
+			case "div", "section", "ul", "ol", "table", "header":
+				hasImg := findHTMLNode(c, "img") != nil
+				if articleClassWeight(c) < 0 || linkDensity(c) > 0.5 || textLen < 25 && !hasImg && findHTMLNode(c, "pre") == nil {
+					removed = append(removed, c)
+					continue
+				}
+			case "h1", "h2":
+				if title != "" && strings.EqualFold(text, title) {
+					removed = append(removed, c)
+					continue
+				}
+			case "p":
+				if textLen == 0 && findHTMLNode(c, "img") == nil {
+					removed = append(removed, c)
+					continue
+				}
+			}
+			walk(c)
+		}
+	}
+	walk(root)
+	for _, n := range removed {
+		n.Parent.RemoveChild(n)
+	}
+	walkHTML(root, func(n *html.Node) {
+		attrs := n.Attr[:0]
+		for _, a := range n.Attr {
+			if articleKeptAttrs[a.Key] {
+				if a.Key == "href" || a.Key == "src" {
+					a.Val = resolveURL(base, a.Val)
+				}
+				attrs = append(attrs, a)
+			}
+		}
+		n.Attr = attrs
+	})
+}
+
+func findHTMLNode(n *html.Node, tag string) (res *html.Node) {
+	walkHTML(n, func(c *html.Node) {
+		if res == nil && c.Data == tag {
+			res = c
+		}
+	})
+	return
+}
+
+//----------- article meta-info -------------
+
+// jsonLDArticle finds JSON-LD object of Article type (Article, NewsArticle, BlogPosting, etc)
+func jsonLDArticle(objects []js.Object) js.Object {
+	for _, obj := range objects {
+		if isJSONLDArticle(obj["@type"]) {
+			return obj
+		}
+		for _, o := range asAnyArray(obj["@graph"]) {
+			if obj, ok := asJSONObject(o); ok && isJSONLDArticle(obj["@type"]) {
+				return obj
+			}
+		}
+	}
+	return js.Object{}
+}
+
+func isJSONLDArticle(typ any) bool {
+	for _, t := range append(asAnyArray(typ), typ) {
+		if s, ok := t.(string); ok && (strings.HasSuffix(s, "Article") || s == "BlogPosting" || s == "Report") {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonLDString returns string value of JSON-LD property (text, first element of array or "url"/"name" of object)
+func jsonLDString(v any) string {
+	if arr, ok := asJSONArray(v); ok && len(arr) > 0 {
+		v = arr[0]
+	}
+	if obj, ok := asJSONObject(v); ok {
+		if s, _ := obj["url"].(string); s != "" {
+			return s
+		}
+		v = obj["name"]
+	}
+	s, _ := v.(string)
+	return strings.TrimSpace(s)
+}
+
+func asAnyArray(v any) []any {
+	arr, _ := asJSONArray(v)
+	return arr
+}
+
+func articleTitle(root *html.Node, meta *Metadata, ld js.Object) string {
+	if s := strings.TrimSpace(meta.OpenGraph.Get("og:title")); s != "" {
+		return s
+	}
+	if s := jsonLDString(ld["headline"]); s != "" {
+		return s
+	}
+	title := meta.Title
+	if h1 := findHTMLNode(root, "h1"); h1 != nil {
+		if s := htmlNodeText(h1); s != "" && (title == "" || strings.Contains(title, s)) {
+			return s
+		}
+	}
+	if parts := reTitleSeparator.Split(title, -1); len(parts) > 1 && len(strings.Fields(parts[0])) >= 3 {
+		return parts[0]
+	}
+	return title
+}
+
+func articleByline(meta *Metadata, ld js.Object) string {
+	var names []string
+	authors := asAnyArray(ld["author"])
+	if authors == nil && ld["author"] != nil {
+		authors = []any{ld["author"]}
+	}
+	for _, author := range authors {
+		if obj, ok := asJSONObject(author); ok {
+			author = obj["name"]
+		}
+		if name := jsonLDString(author); name != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) > 0 {
+		return strings.Join(names, ", ")
+	}
+	if s := meta.Meta.Get("author"); s != "" {
+		return s
+	}
+	if s := meta.OpenGraph.Get("article:author"); s != "" && !strings.Contains(s, "://") {
+		return s
+	}
+	return ""
+}
+
+func articlePublished(root *html.Node, meta *Metadata, ld js.Object) time.Time {
+	values := []string{
+		meta.OpenGraph.Get("article:published_time"),
+		jsonLDString(ld["datePublished"]),
+		meta.Meta.Get("pubdate"),
+		meta.Meta.Get("publishdate"),
+		meta.Meta.Get("date"),
+		meta.DublinCore.Get("dc.date"),
+		meta.DublinCore.Get("dcterms.created"),
+	}
+	for _, item := range meta.Microdata {
+		values = append(values, item.Get("datePublished"))
+	}
+	walkHTML(root, func(n *html.Node) {
+		if n.Data == "time" {
+			values = append(values, htmlAttr(n, "datetime"))
+		}
+	})
+	for _, s := range values {
+		if t, ok := parseTime(s); ok {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,50 @@
+package httpdoc
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const testArticleHTML = `<!DOCTYPE html>
+<html><head>
+	<title>Why gophers dig tunnels | Nature Blog</title>
+	<meta property="article:published_time" content="2024-03-15T10:00:00Z">
+	<script type="application/ld+json">{"@context":"https://schema.org","@graph":[{"@type":"WebSite","name":"Nature Blog"},
+		{"@type":"NewsArticle","headline":"Why gophers dig tunnels","author":[{"@type":"Person","name":"Jane Doe"}]}]}</script>
+</head><body>
+	<div id="cookie-banner">We use cookies to improve your experience, please accept all of them right now.</div>
+	<nav><a href="/">Home</a> <a href="/news">News</a> <a href="/about">About us and our long story</a></nav>
+	<div class="layout">
+		<div class="sidebar"><ul><li><a href="/a">Popular article number one about something</a></li><li><a href="/b">Popular article number two</a></li></ul></div>
+		<div class="post-content">
+			<h1>Why gophers dig tunnels</h1>
+			<p class="byline">By Jane Doe</p>
+			<p>Gophers spend most of their lives underground, digging extensive tunnel systems, which protect them from predators.</p>
+			<p>The tunnels can be hundreds of meters long, with separate chambers for food storage, nesting and waste.</p>
+			<figure><img src="/img/tunnel.jpg" alt="tunnel" style="width:100%"></figure>
+			<p>Scientists believe, that tunnel systems also help gophers to regulate temperature, humidity and access to roots.</p>
+			<div class="share-links"><a href="https://x.com/share">Share on X</a> <a href="https://fb.com/share">Share on Facebook</a></div>
+		</div>
+		<div class="comments"><p>First! This comment is long enough to be a paragraph, isn't it, dear readers?</p></div>
+	</div>
+	<footer>Copyright 2024, Nature Blog, all rights reserved, contact us for anything.</footer>
+</body></html>`
+
+func TestDocument_Article(t *testing.T) {
+	srv := newTestServer(t, testArticleHTML)
+
+	a := newDocument(srv.URL+"/blog/gophers", NewClient(), nil).Article()
+
+	assert(t, a.Title == "Why gophers dig tunnels")
+	assert(t, a.Byline == "Jane Doe")
+	assert(t, a.Published.Equal(time.Date(2024, 3, 15, 10, 0, 0, 0, time.UTC)))
+	assert(t, a.Image == srv.URL+"/img/tunnel.jpg")
+
+	assert(t, strings.HasPrefix(a.Text, "Gophers spend most of their lives underground"))
+	assert(t, strings.HasSuffix(a.Text, "humidity and access to roots."))
+	assert(t, strings.Contains(a.HTML, `<img src="`+srv.URL+`/img/tunnel.jpg" alt="tunnel"/>`))
+	for _, s := range []string{"cookies", "Popular", "Share", "First!", "Copyright", "By Jane Doe", "<h1>"} {
+		assert(t, !strings.Contains(a.HTML, s))
+	}
+}
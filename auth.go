@@ -0,0 +1,433 @@
+package httpdoc
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SetBearerAuth sets header "Authorization: Bearer <token>"
+func (d *Document) SetBearerAuth(token string) *Document {
+	d.Request.Header.Set("Authorization", "Bearer "+token)
+	return d
+}
+
+// SetDigestAuth makes document to answer HTTP Digest authentication challenge (RFC 7616)
+func (d *Document) SetDigestAuth(username, password string) *Document {
+	return d.Use(DigestAuth(username, password))
+}
+
+func (s *Session) SetBasicAuth(username, password string) *Session {
+	r := &http.Request{Header: http.Header{}}
+	r.SetBasicAuth(username, password)
+	return s.SetHeader("Authorization", r.Header.Get("Authorization"))
+}
+
+func (s *Session) SetBearerAuth(token string) *Session {
+	return s.SetHeader("Authorization", "Bearer "+token)
+}
+
+func (s *Session) SetDigestAuth(username, password string) *Session {
+	return s.Use(DigestAuth(username, password))
+}
+
+// BearerAuth creates middleware that sets bearer token to all requests
+func BearerAuth(token string) Middleware {
+	return BeforeRequest(func(d *Document) error {
+		d.SetBearerAuth(token)
+		return nil
+	})
+}
+
+//--------------- Digest authentication (RFC 7616) -----------------
+
+// DigestAuth creates middleware for HTTP Digest authentication (RFC 7616); next requests are authorized preemptively
+func DigestAuth(username, password string) Middleware {
+	a := &digestAuth{username: username, password: password}
+	return a.handle
+}
+
+type digestAuth struct {
+	username  string
+	password  string
+	mx        sync.Mutex
+	challenge *digestChallenge
+	nc        uint32
+}
+
+type digestChallenge struct {
+	realm     string
+	nonce     string
+	opaque    string
+	algorithm string
+	qop       string
+	userhash  bool
+}
+
+func (a *digestAuth) handle(d *Document, next func() error) error {
+	a.mx.Lock()
+	cached := a.challenge != nil
+	a.mx.Unlock()
+
+	if cached {
+		if err := a.authorize(d); err != nil {
+			return err
+		}
+	}
+	if err := next(); err != nil || d.Response.StatusCode != http.StatusUnauthorized {
+		return err
+	}
+	ch := parseDigestChallenge(d.Response.Header.Values("WWW-Authenticate"))
+	if ch == nil {
+		return nil // not digest-authentication
+	}
+	a.mx.Lock()
+	a.challenge, a.nc = ch, 0
+	a.mx.Unlock()
+
+	if err := a.authorize(d); err != nil {
+		return err
+	}
+	return next()
+}
+
+func (a *digestAuth) authorize(d *Document) error {
+	a.mx.Lock()
+	ch := a.challenge
+	a.nc++
+	nc := fmt.Sprintf("%08x", a.nc)
+	a.mx.Unlock()
+
+	newHash := digestHashFunc(ch.algorithm)
+	h := func(s string) string {
+		hs := newHash()
+		hs.Write([]byte(s))
+		return hex.EncodeToString(hs.Sum(nil))
+	}
+	cnonce := randomHex(16)
+	uri := d.Request.URL.RequestURI()
+
+	ha1 := h(a.username + ":" + ch.realm + ":" + a.password)
+	if strings.HasSuffix(strings.ToUpper(ch.algorithm), "-SESS") {
+		ha1 = h(ha1 + ":" + ch.nonce + ":" + cnonce)
+	}
+	ha2 := h(d.Request.Method + ":" + uri)
+	if ch.qop == "auth-int" {
+		body, err := d.requestBody()
+		if err != nil {
+			return err
+		}
+		ha2 = h(d.Request.Method + ":" + uri + ":" + h(string(body)))
+	}
+	var response string
+	if ch.qop != "" {
+		response = h(ha1 + ":" + ch.nonce + ":" + nc + ":" + cnonce + ":" + ch.qop + ":" + ha2)
+	} else {
+		response = h(ha1 + ":" + ch.nonce + ":" + ha2)
+	}
+	username := a.username
+	if ch.userhash {
+		username = h(a.username + ":" + ch.realm)
+	}
+	auth := fmt.Sprintf(`Digest username="%s", realm="%s", nonce="%s", uri="%s", response="%s"`,
+		escapeQuotes(username), escapeQuotes(ch.realm), escapeQuotes(ch.nonce), escapeQuotes(uri), response)
+	if ch.algorithm != "" {
+		auth += ", algorithm=" + ch.algorithm
+	}
+	if ch.opaque != "" {
+		auth += fmt.Sprintf(`, opaque="%s"`, escapeQuotes(ch.opaque))
+	}
+	if ch.qop != "" {
+		auth += fmt.Sprintf(`, qop=%s, nc=%s, cnonce="%s"`, ch.qop, nc, cnonce)
+	}
+	if ch.userhash {
+		auth += ", userhash=true"
+	}
+	d.Request.Header.Set("Authorization", auth)
+	return nil
+}
+
+var digestAlgorithms = map[string]func() hash.Hash{
+	"MD5":         md5.New,
+	"SHA-256":     sha256.New,
+	"SHA-512-256": sha512.New512_256,
+}
+
+// digestAlgorithmsPriority lists supported algorithms from the strongest
+var digestAlgorithmsPriority = []string{"SHA-512-256", "SHA-256", "MD5"}
+
+func digestHashFunc(algorithm string) func() hash.Hash {
+	if fn := digestAlgorithms[strings.TrimSuffix(strings.ToUpper(algorithm), "-SESS")]; fn != nil {
+		return fn
+	}
+	return md5.New
+}
+
+// parseDigestChallenge selects the strongest supported Digest challenge from WWW-Authenticate headers
+func parseDigestChallenge(headers []string) (best *digestChallenge) {
+	rank := func(ch *digestChallenge) int {
+		alg := strings.TrimSuffix(strings.ToUpper(ch.algorithm), "-SESS")
+		if alg == "" {
+			alg = "MD5"
+		}
+		for i, a := range digestAlgorithmsPriority {
+			if a == alg {
+				return len(digestAlgorithmsPriority) - i
+			}
+		}
+		return 0
+	}
+	for _, header := range headers {
+		for _, params := range splitAuthChallenges(header) {
+			if params["_scheme"] != "digest" {
+				continue
+			}
+			ch := &digestChallenge{
+				realm:     params["realm"],
+				nonce:     params["nonce"],
+				opaque:    params["opaque"],
+				algorithm: params["algorithm"],
+				userhash:  strings.EqualFold(params["userhash"], "true"),
+			}
+			if qops := params["qop"]; qops != "" {
+				for _, q := range strings.Split(qops, ",") {
+					if q = strings.TrimSpace(strings.ToLower(q)); q == "auth" || q == "auth-int" && ch.qop == "" {
+						ch.qop = q
+					}
+				}
+				if ch.qop == "" {
+					continue
+				}
+			}
+			if rank(ch) > 0 && (best == nil || rank(ch) > rank(best)) {
+				best = ch
+			}
+		}
+	}
+	return
+}
+
+// splitAuthChallenges parses value of WWW-Authenticate header to list of challenges.
+// Auth-scheme (lower case) is stored with key "_scheme".
+func splitAuthChallenges(s string) (challenges []map[string]string) {
+	var cur map[string]string
+	i := 0
+	skip := func(chars string) {
+		for i < len(s) && strings.IndexByte(chars, s[i]) >= 0 {
+			i++
+		}
+	}
+	for {
+		skip(" \t,")
+		if i >= len(s) {
+			return
+		}
+		start := i
+		for i < len(s) && strings.IndexByte(" \t,=", s[i]) < 0 {
+			i++
+		}
+		token := s[start:i]
+		skip(" \t")
+		if token == "" {
+			i++
+			continue
+		}
+		if i < len(s) && s[i] == '=' && cur != nil { // auth-param
+			if i++; i < len(s) && s[i] == '=' { // token68
+				skip("=")
+				continue
+			}
+			skip(" \t")
+			var val strings.Builder
+			if i < len(s) && s[i] == '"' {
+				for i++; i < len(s) && s[i] != '"'; i++ {
+					if s[i] == '\\' && i+1 < len(s) {
+						i++
+					}
+					val.WriteByte(s[i])
+				}
+				i++
+			} else {
+				for ; i < len(s) && s[i] != ','; i++ {
+					val.WriteByte(s[i])
+				}
+			}
+			cur[strings.ToLower(token)] = strings.TrimSpace(val.String())
+			continue
+		}
+		cur = map[string]string{"_scheme": strings.ToLower(token)}
+		challenges = append(challenges, cur)
+	}
+}
+
+func randomHex(n int) string {
+	buf := make([]byte, n)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+//--------------- OAuth2 -----------------
+
+// OAuth2Config describes OAuth2 client-credentials or refresh-token flow (RFC 6749)
+type OAuth2Config struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	RefreshToken string       // if set, the refresh-token grant is used instead of client-credentials
+	Params       url.Values   // additional params of token request (audience, resource, etc.)
+	AuthInParams bool         // send client credentials in request body instead of Basic-authorization header
+	Client       *http.Client // client for token requests (DefaultClient by default)
+}
+
+type OAuth2Token struct {
+	AccessToken  string    `json:"access_token"`
+	TokenType    string    `json:"token_type"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	ExpiresIn    int64     `json:"expires_in,omitempty"`
+	Expiry       time.Time `json:"-"`
+}
+
+// Valid reports whether token is set and is not going to expire in the next 10 seconds
+func (t *OAuth2Token) Valid() bool {
+	return t != nil && t.AccessToken != "" && (t.Expiry.IsZero() || time.Now().Add(10*time.Second).Before(t.Expiry))
+}
+
+// OAuth2TokenSource fetches access token and refreshes it when it expires. It is safe for concurrent use.
+type OAuth2TokenSource struct {
+	cfg   OAuth2Config
+	mx    sync.Mutex
+	token *OAuth2Token
+}
+
+func NewOAuth2TokenSource(cfg OAuth2Config) *OAuth2TokenSource {
+	return &OAuth2TokenSource{cfg: cfg}
+}
+
+// OAuth2Auth creates middleware that authorizes requests by OAuth2 access token
+func OAuth2Auth(cfg OAuth2Config) Middleware {
+	return NewOAuth2TokenSource(cfg).Middleware()
+}
+
+// Token returns valid access token; fetches new one if needed
+func (s *OAuth2TokenSource) Token() (*OAuth2Token, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if s.token.Valid() {
+		return s.token, nil
+	}
+	refreshToken := s.cfg.RefreshToken
+	if s.token != nil && s.token.RefreshToken != "" {
+		refreshToken = s.token.RefreshToken
+	}
+	t, err := s.fetchToken(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	s.token = t
+	return t, nil
+}
+
+// Invalidate forces fetching of new token on the next request
+func (s *OAuth2TokenSource) Invalidate() {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if s.token != nil {
+		s.token.AccessToken = ""
+	}
+}
+
+// Middleware creates middleware that sets access token to requests.
+// On 401 response the token is refreshed and the request is resent once.
+func (s *OAuth2TokenSource) Middleware() Middleware {
+	return func(d *Document, next func() error) error {
+		for attempt := 0; ; attempt++ {
+			t, err := s.Token()
+			if err != nil {
+				return err
+			}
+			tokenType := t.TokenType
+			if tokenType == "" || strings.EqualFold(tokenType, "bearer") {
+				tokenType = "Bearer"
+			}
+			d.Request.Header.Set("Authorization", tokenType+" "+t.AccessToken)
+			if err = next(); err != nil || d.Response.StatusCode != http.StatusUnauthorized || attempt > 0 {
+				return err
+			}
+			s.Invalidate()
+		}
+	}
+}
+
+func (s *OAuth2TokenSource) fetchToken(refreshToken string) (*OAuth2Token, error) {
+	cfg := s.cfg
+	client := cfg.Client
+	if client == nil {
+		client = DefaultClient
+	}
+	params := url.Values{}
+	for name, vals := range cfg.Params {
+		params[name] = vals
+	}
+	if refreshToken != "" {
+		params.Set("grant_type", "refresh_token")
+		params.Set("refresh_token", refreshToken)
+	} else {
+		params.Set("grant_type", "client_credentials")
+	}
+	if len(cfg.Scopes) > 0 {
+		params.Set("scope", strings.Join(cfg.Scopes, " "))
+	}
+	doc := newDocument(cfg.TokenURL, client, nil)
+	doc.noMiddlewares = true
+	doc.SetHeader("Accept", "application/json")
+	if cfg.AuthInParams {
+		params.Set("client_id", cfg.ClientID)
+		if cfg.ClientSecret != "" {
+			params.Set("client_secret", cfg.ClientSecret)
+		}
+	} else if cfg.ClientID != "" {
+		doc.SetBasicAuth(url.QueryEscape(cfg.ClientID), url.QueryEscape(cfg.ClientSecret))
+	}
+	doc.SetPOSTParams(params)
+
+	var resp struct {
+		OAuth2Token
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	loadErr := doc.Load()
+	if doc.Response == nil {
+		return nil, loadErr
+	}
+	if err := doc.GetJSON(&resp); err != nil && loadErr == nil {
+		return nil, err
+	}
+	if resp.Error != "" {
+		return nil, errors.New(strings.TrimSpace("httpdoc.OAuth2: " + resp.Error + " " + resp.ErrorDescription))
+	}
+	if loadErr != nil {
+		return nil, loadErr
+	}
+	if resp.AccessToken == "" {
+		return nil, errors.New("httpdoc.OAuth2: empty access token")
+	}
+	t := resp.OAuth2Token
+	if t.ExpiresIn > 0 {
+		t.Expiry = time.Now().Add(time.Duration(t.ExpiresIn) * time.Second)
+	}
+	if t.RefreshToken == "" {
+		t.RefreshToken = refreshToken
+	}
+	return &t, nil
+}
@@ -0,0 +1,83 @@
+package httpdoc
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestDigestAuth(t *testing.T) {
+	const realm, nonce = "test@example.org", "7ypf/xlj9XXwfDPEoM4URrv"
+	h := func(s string) string {
+		sum := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(sum[:])
+	}
+	var requests int
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		var p map[string]string
+		if cc := splitAuthChallenges(r.Header.Get("Authorization")); len(cc) == 1 {
+			p = cc[0]
+		}
+		ha1 := h("Mufasa:" + realm + ":Circle of Life")
+		ha2 := h(r.Method + ":" + r.URL.RequestURI())
+		if p == nil || p["response"] != h(ha1+":"+nonce+":"+p["nc"]+":"+p["cnonce"]+":auth:"+ha2) {
+			w.Header().Add("WWW-Authenticate", `Basic realm="`+realm+`"`)
+			w.Header().Add("WWW-Authenticate", fmt.Sprintf(`Digest realm="%s", qop="auth, auth-int", algorithm=MD5, nonce="%s"`, realm, nonce))
+			w.Header().Add("WWW-Authenticate", fmt.Sprintf(`Digest realm="%s", qop="auth, auth-int", algorithm=SHA-256, nonce="%s", opaque="x"`, realm, nonce))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("<title>" + p["nc"] + "</title>"))
+	})
+
+	s := NewSession().SetDigestAuth("Mufasa", "Circle of Life")
+
+	doc := s.NewDocument(srv.URL + "/dir/index.html?q=1")
+	assert(t, doc.Load() == nil)
+	assert(t, "00000001" == doc.Title())
+	assert(t, 2 == requests)
+
+	doc = doc.NewDoc("/other") // authorized preemptively
+	assert(t, "00000002" == doc.Title())
+	assert(t, 3 == requests)
+
+	doc = newDocument(srv.URL, NewClient(), nil).SetDigestAuth("Mufasa", "wrong")
+	assert(t, doc.Load() != nil)
+	assert(t, 401 == doc.Response.StatusCode)
+}
+
+func TestOAuth2Auth(t *testing.T) {
+	var tokens int
+	tokenSrv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		if id != "client" || secret != "secret" || r.PostFormValue("grant_type") != "client_credentials" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_client"}`))
+			return
+		}
+		tokens++
+		fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"bearer","expires_in":3600}`, tokens)
+	})
+	apiSrv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth == "Bearer token-1" { // revoked token
+			w.WriteHeader(http.StatusUnauthorized)
+		} else {
+			w.Write([]byte(`{"auth":"` + auth + `"}`))
+		}
+	})
+
+	s := NewSession().Use(OAuth2Auth(OAuth2Config{TokenURL: tokenSrv.URL, ClientID: "client", ClientSecret: "secret"}))
+
+	var res struct{ Auth string }
+	assert(t, s.LoadJSON(apiSrv.URL, &res) == nil)
+	assert(t, "Bearer token-2" == res.Auth)
+	assert(t, s.LoadJSON(apiSrv.URL, &res) == nil)
+	assert(t, 2 == tokens)
+
+	s = NewSession().Use(OAuth2Auth(OAuth2Config{TokenURL: tokenSrv.URL, ClientID: "client", ClientSecret: "wrong"}))
+	err := s.LoadJSON(apiSrv.URL, &res)
+	assert(t, err != nil && err.Error() == "httpdoc.OAuth2: invalid_client")
+}
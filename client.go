@@ -1,21 +1,175 @@
 package httpdoc
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
 	"net/http"
-	"net/http/cookiejar"
+	"os"
 	"time"
-	//"crypto/tls"
 )
 
 var DefaultClient = NewClient()
 
-func NewClient() *http.Client {
-	jar, _ := cookiejar.New(nil)
+// ClientOptions describes transport settings of http-client
+type ClientOptions struct {
+	Timeout               time.Duration // total request timeout (http.Client.Timeout)
+	DialTimeout           time.Duration
+	KeepAlive             time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+	IdleConnTimeout       time.Duration
+	MaxIdleConns          int
+	MaxIdleConnsPerHost   int
+	HTTP2                 bool
+	RootCAs               *x509.CertPool    // custom root certificates; nil means system pool
+	Certificates          []tls.Certificate // client certificates (mTLS)
+	InsecureSkipVerify    bool              // don't verify server certificates (for test environments only)
+	LocalAddr             string            // local ip-address (or "ip:port") to bind outgoing connections
+}
+
+type ClientOption func(*ClientOptions)
+
+var DefaultClientOptions = ClientOptions{
+	Timeout:             60 * time.Second,
+	DialTimeout:         30 * time.Second,
+	KeepAlive:           30 * time.Second,
+	TLSHandshakeTimeout: 10 * time.Second,
+	IdleConnTimeout:     90 * time.Second,
+	MaxIdleConns:        100,
+	MaxIdleConnsPerHost: http.DefaultMaxIdleConnsPerHost,
+	HTTP2:               true,
+}
+
+func newClientOptions(opts []ClientOption) ClientOptions {
+	o := DefaultClientOptions
+	for _, fn := range opts {
+		fn(&o)
+	}
+	return o
+}
+
+func WithTimeout(d time.Duration) ClientOption {
+	return func(o *ClientOptions) { o.Timeout = d }
+}
+
+func WithDialTimeout(d time.Duration) ClientOption {
+	return func(o *ClientOptions) { o.DialTimeout = d }
+}
+
+func WithKeepAlive(d time.Duration) ClientOption {
+	return func(o *ClientOptions) { o.KeepAlive = d }
+}
+
+func WithTLSHandshakeTimeout(d time.Duration) ClientOption {
+	return func(o *ClientOptions) { o.TLSHandshakeTimeout = d }
+}
+
+func WithResponseHeaderTimeout(d time.Duration) ClientOption {
+	return func(o *ClientOptions) { o.ResponseHeaderTimeout = d }
+}
+
+func WithIdleConnTimeout(d time.Duration) ClientOption {
+	return func(o *ClientOptions) { o.IdleConnTimeout = d }
+}
+
+func WithMaxIdleConnsPerHost(n int) ClientOption {
+	return func(o *ClientOptions) { o.MaxIdleConnsPerHost = n }
+}
+
+func WithHTTP2(enabled bool) ClientOption {
+	return func(o *ClientOptions) { o.HTTP2 = enabled }
+}
+
+func WithRootCAs(pool *x509.CertPool) ClientOption {
+	return func(o *ClientOptions) { o.RootCAs = pool }
+}
+
+func WithClientCertificates(certs ...tls.Certificate) ClientOption {
+	return func(o *ClientOptions) { o.Certificates = append(o.Certificates, certs...) }
+}
+
+func WithInsecureSkipVerify(skip bool) ClientOption {
+	return func(o *ClientOptions) { o.InsecureSkipVerify = skip }
+}
+
+func WithLocalAddr(addr string) ClientOption {
+	return func(o *ClientOptions) { o.LocalAddr = addr }
+}
+
+// LoadCertPool creates cert-pool from PEM-files
+func LoadCertPool(pemFiles ...string) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	for _, file := range pemFiles {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("httpdoc: no certificates found in %s", file)
+		}
+	}
+	return pool, nil
+}
+
+func NewClient(opts ...ClientOption) *http.Client {
+	o := newClientOptions(opts)
 	return &http.Client{
-		Jar:     jar,
-		Timeout: 60 * time.Second,
-		//Transport: &http.Transport{  // use HTTP/2 by default
-		//	TLSNextProto: make(map[string]func(string,  *tls.Conn) http.RoundTripper),
-		//},
+		Jar:       NewCookieJar(),
+		Timeout:   o.Timeout,
+		Transport: o.newTransport(),
+	}
+}
+
+func NewTransport(opts ...ClientOption) *http.Transport {
+	o := newClientOptions(opts)
+	return o.newTransport()
+}
+
+func (o ClientOptions) newDialer() *net.Dialer {
+	dialer := &net.Dialer{
+		Timeout:   o.DialTimeout,
+		KeepAlive: o.KeepAlive,
+	}
+	if o.LocalAddr != "" {
+		addr := o.LocalAddr
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			addr = net.JoinHostPort(addr, "0")
+		}
+		tcpAddr, err := net.ResolveTCPAddr("tcp", addr)
+		panicOnErr(err)
+		dialer.LocalAddr = tcpAddr
+	}
+	return dialer
+}
+
+func (o ClientOptions) newTLSConfig() *tls.Config {
+	if o.RootCAs == nil && len(o.Certificates) == 0 && !o.InsecureSkipVerify {
+		return nil
+	}
+	return &tls.Config{
+		RootCAs:            o.RootCAs,
+		Certificates:       o.Certificates,
+		InsecureSkipVerify: o.InsecureSkipVerify,
+	}
+}
+
+func (o ClientOptions) newTransport() *http.Transport {
+	tr := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           o.newDialer().DialContext,
+		TLSClientConfig:       o.newTLSConfig(),
+		TLSHandshakeTimeout:   o.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: o.ResponseHeaderTimeout,
+		IdleConnTimeout:       o.IdleConnTimeout,
+		MaxIdleConns:          o.MaxIdleConns,
+		MaxIdleConnsPerHost:   o.MaxIdleConnsPerHost,
+		ExpectContinueTimeout: time.Second,
+		ForceAttemptHTTP2:     o.HTTP2,
+	}
+	if !o.HTTP2 { // disable HTTP/2
+		tr.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
 	}
+	return tr
 }
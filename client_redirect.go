@@ -0,0 +1,107 @@
+package httpdoc
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// maxRefreshDelay is max delay (in seconds) of meta refresh which is considered as redirect
+const maxRefreshDelay = 10
+
+var (
+	reJSRedirect = regexp.MustCompile(`(?:\b(?:window|document|self|top)\.)?\blocation(?:\.href)?\s*=\s*(?:"([^"]*)"|'([^']*)')|` +
+		`\blocation\.(?:replace|assign)\(\s*(?:"([^"]*)"|'([^']*)')\s*\)`)
+
+	// reJSNotTrivial finds code of script where redirect can be conditional or be handler of event
+	reJSNotTrivial = regexp.MustCompile(`\bfunction\b|=>|addEventListener|\bon[a-z]+\s*=|\bif\s*\(`)
+)
+
+// FollowClientRedirects makes Load follow meta refresh and trivial js-redirects (window.location = "...") up to maxHops.
+// Followed pages are recorded in History.
+func (d *Document) FollowClientRedirects(maxHops int) *Document {
+	d.clientRedirects = maxHops
+	return d
+}
+
+// History returns urls of loading of document: urls of http-redirects and of followed client-side redirects.
+// The last url is url of document.
+func (d *Document) History() (urls []*url.URL) {
+	for _, resp := range append(append([]*http.Response{}, d.history...), d.Response) {
+		var chain []*url.URL
+		for ; resp != nil && resp.Request != nil; resp = resp.Request.Response {
+			chain = append([]*url.URL{resp.Request.URL}, chain...)
+		}
+		urls = append(urls, chain...)
+	}
+	return
+}
+
+// followClientRedirects loads targets of client-side redirects and replaces response and content of document
+func (d *Document) followClientRedirects() error {
+	visited := map[string]bool{visitedKey(d.URL()): true}
+	for hops := 0; ; hops++ {
+		target := d.clientRedirect()
+		if target == "" {
+			return nil
+		}
+		next := d.NewDoc(target)
+		if u := next.URL(); u.Scheme != "http" && u.Scheme != "https" {
+			return nil
+		}
+		key := visitedKey(next.URL())
+		if key == visitedKey(d.URL()) {
+			return nil // refresh of the same page
+		}
+		if visited[key] {
+			return fmt.Errorf("httpdoc: loop of client redirects to %s", next.URL())
+		}
+		if hops >= d.clientRedirects {
+			return fmt.Errorf("httpdoc: stopped after %d client redirects", d.clientRedirects)
+		}
+		visited[key] = true
+		next.clientRedirects = 0
+		next.middlewares.list = d.middlewares.funcs()
+		next.signers = d.signers
+		err := next.Load()
+		d.history = append(d.history, d.Response)
+		d.Response, d.rawBody, d.Body = next.Response, next.rawBody, next.Body
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// clientRedirect returns url of meta refresh or javascript redirect of html-document
+func (d *Document) clientRedirect() (target string) {
+	if ct := d.ContentType(); ct != "" && !strings.Contains(ct, "html") {
+		return ""
+	}
+	walkHTML(d.htmlRoot(), func(n *html.Node) {
+		if target != "" {
+			return
+		}
+		switch n.Data {
+		case "meta":
+			if strings.EqualFold(htmlAttr(n, "http-equiv"), "refresh") {
+				if delay, href, ok := parseMetaRefresh(htmlAttr(n, "content")); ok && delay <= maxRefreshDelay {
+					target = href
+				}
+			}
+		case "script":
+			if src := htmlNodeRawText(n); !hasHTMLAttr(n, "src") && !reJSNotTrivial.MatchString(src) {
+				if ss := reJSRedirect.FindStringSubmatch(src); ss != nil {
+					target = strings.ReplaceAll(strings.TrimSpace(ss[1]+ss[2]+ss[3]+ss[4]), `\/`, "/")
+				}
+			}
+		}
+		if _, err := url.Parse(target); err != nil {
+			target = ""
+		}
+	})
+	return
+}
@@ -0,0 +1,53 @@
+package httpdoc
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDocument_FollowClientRedirects(t *testing.T) {
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/start":
+			http.Redirect(w, r, "/meta", http.StatusFound)
+		case "/meta":
+			w.Write([]byte(`<html><head><meta http-equiv="Refresh" content="0; URL='/js'"></head></html>`))
+		case "/js":
+			w.Write([]byte(`<html><body><script>window.location.href = "\/final?from=" + "js";</script></body></html>`))
+		case "/final":
+			fmt.Fprintf(w, `<html><head><meta http-equiv="refresh" content="300"></head><body>final %s</body></html>`, r.Referer())
+		case "/loop":
+			w.Write([]byte(`<script>location.replace('/loop2')</script>`))
+		case "/loop2":
+			w.Write([]byte(`<script>location.replace('/loop')</script>`))
+		case "/handler":
+			w.Write([]byte(`<script>btn.onclick = function() { location.href = "/final" }</script>`))
+		}
+	})
+
+	// disabled by default
+	doc := newDocument(srv.URL+"/meta", NewClient(), nil)
+	assert(t, doc.Load() == nil && doc.URL().Path == "/meta")
+
+	doc = newDocument(srv.URL+"/start", NewClient(), nil).FollowClientRedirects(5)
+	assert(t, doc.Load() == nil)
+	assert(t, doc.URL().Path == "/final")
+	assert(t, strings.Contains(doc.ContentStr(), "final "+srv.URL+"/js"))
+	var history []string
+	for _, u := range doc.History() {
+		history = append(history, u.Path)
+	}
+	assert(t, strings.Join(history, " ") == "/start /meta /js /final")
+
+	// hop limit and loops
+	err := newDocument(srv.URL+"/start", NewClient(), nil).FollowClientRedirects(1).Load()
+	assert(t, err != nil && err.Error() == "httpdoc: stopped after 1 client redirects")
+	err = newDocument(srv.URL+"/loop", NewClient(), nil).FollowClientRedirects(3).Load()
+	assert(t, err != nil && strings.Contains(err.Error(), "loop of client redirects"))
+
+	// event handlers are not followed
+	doc = newDocument(srv.URL+"/handler", NewClient(), nil).FollowClientRedirects(3)
+	assert(t, doc.Load() == nil && doc.URL().Path == "/handler")
+}
@@ -0,0 +1,22 @@
+package httpdoc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientInsecureSkipVerify(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<title>TLS</title>"))
+	}))
+	defer srv.Close()
+
+	doc := newDocument(srv.URL, NewClient(), nil)
+	assert(t, doc.Load() != nil) // self-signed certificate
+
+	doc = newDocument(srv.URL, NewClient(WithInsecureSkipVerify(true), WithHTTP2(false)), nil)
+	assert(t, doc.Load() == nil)
+	assert(t, "TLS" == doc.Title())
+	assert(t, "HTTP/1.1" == doc.Response.Proto)
+}
@@ -0,0 +1,650 @@
+package httpdoc
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/idna"
+	"golang.org/x/net/publicsuffix"
+)
+
+// CookieJar implements http.CookieJar (RFC 6265) with public suffix list,
+// enumeration of cookies, and import/export to Netscape cookies.txt and JSON-formats.
+type CookieJar struct {
+	mx       sync.Mutex
+	psList   cookiejar.PublicSuffixList
+	entries  map[string]map[string]*jarEntry // eTLD+1 -> cookie id -> entry
+	seqNum   uint64
+	filename string
+}
+
+type jarEntry struct {
+	Name       string
+	Value      string
+	Domain     string
+	Path       string
+	SameSite   http.SameSite
+	Secure     bool
+	HttpOnly   bool
+	Persistent bool
+	HostOnly   bool
+	Expires    time.Time
+	Creation   time.Time
+	seqNum     uint64
+}
+
+func (e *jarEntry) id() string {
+	return e.Domain + ";" + e.Path + ";" + e.Name
+}
+
+// NewCookieJar creates in-memory cookie-jar that uses public suffix list
+func NewCookieJar() *CookieJar {
+	return &CookieJar{
+		psList:  publicsuffix.List,
+		entries: map[string]map[string]*jarEntry{},
+	}
+}
+
+// OpenCookieJar creates cookie-jar with cookies of file (".json" or Netscape cookies.txt format); Save writes them back
+func OpenCookieJar(filename string) (*CookieJar, error) {
+	j := NewCookieJar()
+	j.filename = filename
+	if err := j.LoadFile(filename); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return j, nil
+}
+
+// Save writes cookies to the file of jar opened by OpenCookieJar
+func (j *CookieJar) Save() error {
+	if j.filename == "" {
+		return errors.New("httpdoc.CookieJar.Save: file name is not defined")
+	}
+	return j.SaveFile(j.filename)
+}
+
+// SaveFile writes all cookies (including session cookies) to file
+func (j *CookieJar) SaveFile(filename string) error {
+	tmp := filename + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if isJSONFile(filename) {
+		err = j.WriteJSON(f)
+	} else {
+		err = j.WriteNetscape(f)
+	}
+	if err2 := f.Close(); err == nil {
+		err = err2
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, filename)
+}
+
+// LoadFile imports cookies from file
+func (j *CookieJar) LoadFile(filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if isJSONFile(filename) {
+		return j.ReadJSON(f)
+	}
+	return j.ReadNetscape(f)
+}
+
+func isJSONFile(filename string) bool {
+	return strings.ToLower(filepath.Ext(filename)) == ".json"
+}
+
+// SetCookies implements http.CookieJar
+func (j *CookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return
+	}
+	host, err := canonicalHost(u.Host)
+	if err != nil {
+		return
+	}
+	defPath := defaultCookiePath(u.Path)
+	now := time.Now()
+
+	j.mx.Lock()
+	defer j.mx.Unlock()
+	for _, c := range cookies {
+		e, remove, err := j.newEntry(c, now, defPath, host)
+		if err != nil {
+			continue
+		}
+		if remove {
+			j.delete(e.id(), j.key(e.Domain))
+			continue
+		}
+		j.put(e)
+	}
+}
+
+// Cookies implements http.CookieJar
+func (j *CookieJar) Cookies(u *url.URL) (cookies []*http.Cookie) {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil
+	}
+	host, err := canonicalHost(u.Host)
+	if err != nil {
+		return nil
+	}
+	https := u.Scheme == "https"
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+	now := time.Now()
+
+	j.mx.Lock()
+	defer j.mx.Unlock()
+	var selected []*jarEntry
+	for id, e := range j.entries[j.key(host)] {
+		if e.expired(now) {
+			j.delete(id, j.key(host))
+			continue
+		}
+		if e.shouldSend(https, host, path) {
+			selected = append(selected, e)
+		}
+	}
+	sort.Slice(selected, func(i, k int) bool {
+		a, b := selected[i], selected[k]
+		if len(a.Path) != len(b.Path) {
+			return len(a.Path) > len(b.Path)
+		}
+		if !a.Creation.Equal(b.Creation) {
+			return a.Creation.Before(b.Creation)
+		}
+		return a.seqNum < b.seqNum
+	})
+	for _, e := range selected {
+		cookies = append(cookies, &http.Cookie{Name: e.Name, Value: e.Value})
+	}
+	return
+}
+
+// All returns all not expired cookies of jar
+func (j *CookieJar) All() []*http.Cookie {
+	return j.filter(func(*jarEntry) bool { return true })
+}
+
+// Domains returns list of domains that have cookies
+func (j *CookieJar) Domains() (domains []string) {
+	j.mx.Lock()
+	defer j.mx.Unlock()
+	set := map[string]bool{}
+	for _, submap := range j.entries {
+		for _, e := range submap {
+			if !set[e.Domain] {
+				set[e.Domain] = true
+				domains = append(domains, e.Domain)
+			}
+		}
+	}
+	sort.Strings(domains)
+	return
+}
+
+// DomainCookies returns cookies of domain and its subdomains
+func (j *CookieJar) DomainCookies(domain string) []*http.Cookie {
+	domain = normCookieDomain(domain)
+	return j.filter(func(e *jarEntry) bool { return e.inDomain(domain) })
+}
+
+// Delete removes cookie with name from domain and its subdomains; returns number of removed cookies
+func (j *CookieJar) Delete(domain, name string) int {
+	domain = normCookieDomain(domain)
+	return j.remove(func(e *jarEntry) bool { return e.Name == name && e.inDomain(domain) })
+}
+
+// DeleteDomain removes all cookies of domain and its subdomains
+func (j *CookieJar) DeleteDomain(domain string) int {
+	domain = normCookieDomain(domain)
+	return j.remove(func(e *jarEntry) bool { return e.inDomain(domain) })
+}
+
+// Expire sets expiration time for cookies of domain and its subdomains (all cookies if name is empty)
+func (j *CookieJar) Expire(domain, name string, expires time.Time) (n int) {
+	domain = normCookieDomain(domain)
+	j.mx.Lock()
+	defer j.mx.Unlock()
+	for _, submap := range j.entries {
+		for _, e := range submap {
+			if (name == "" || e.Name == name) && e.inDomain(domain) {
+				e.Expires, e.Persistent = expires, true
+				n++
+			}
+		}
+	}
+	return
+}
+
+// RemoveExpired removes expired cookies
+func (j *CookieJar) RemoveExpired() int {
+	now := time.Now()
+	return j.remove(func(e *jarEntry) bool { return e.expired(now) })
+}
+
+// Clear removes all cookies
+func (j *CookieJar) Clear() {
+	j.mx.Lock()
+	defer j.mx.Unlock()
+	j.entries = map[string]map[string]*jarEntry{}
+}
+
+func (j *CookieJar) filter(fn func(*jarEntry) bool) (cookies []*http.Cookie) {
+	now := time.Now()
+	j.mx.Lock()
+	defer j.mx.Unlock()
+	for _, e := range j.sortedEntries() {
+		if !e.expired(now) && fn(e) {
+			cookies = append(cookies, e.cookie())
+		}
+	}
+	return
+}
+
+func (j *CookieJar) remove(fn func(*jarEntry) bool) (n int) {
+	j.mx.Lock()
+	defer j.mx.Unlock()
+	for key, submap := range j.entries {
+		for id, e := range submap {
+			if fn(e) {
+				j.delete(id, key)
+				n++
+			}
+		}
+	}
+	return
+}
+
+func (j *CookieJar) sortedEntries() (ee []*jarEntry) {
+	for _, submap := range j.entries {
+		for _, e := range submap {
+			ee = append(ee, e)
+		}
+	}
+	sort.Slice(ee, func(i, k int) bool {
+		if ee[i].Domain != ee[k].Domain {
+			return ee[i].Domain < ee[k].Domain
+		}
+		return ee[i].seqNum < ee[k].seqNum
+	})
+	return
+}
+
+func (j *CookieJar) key(host string) string {
+	if isIPHost(host) {
+		return host
+	}
+	if key, err := publicsuffix.EffectiveTLDPlusOne(host); err == nil {
+		return key
+	}
+	return host
+}
+
+func (j *CookieJar) put(e *jarEntry) {
+	key := j.key(e.Domain)
+	submap := j.entries[key]
+	if submap == nil {
+		submap = map[string]*jarEntry{}
+		j.entries[key] = submap
+	}
+	if old := submap[e.id()]; old != nil {
+		e.Creation, e.seqNum = old.Creation, old.seqNum
+	} else {
+		j.seqNum++
+		e.seqNum = j.seqNum
+	}
+	submap[e.id()] = e
+}
+
+func (j *CookieJar) delete(id, key string) {
+	if submap := j.entries[key]; submap != nil {
+		delete(submap, id)
+		if len(submap) == 0 {
+			delete(j.entries, key)
+		}
+	}
+}
+
+func (j *CookieJar) newEntry(c *http.Cookie, now time.Time, defPath, host string) (e *jarEntry, remove bool, err error) {
+	e = &jarEntry{
+		Name:     c.Name,
+		Value:    c.Value,
+		Path:     c.Path,
+		Secure:   c.Secure,
+		HttpOnly: c.HttpOnly,
+		SameSite: c.SameSite,
+		Creation: now,
+	}
+	if e.Path == "" || e.Path[0] != '/' {
+		e.Path = defPath
+	}
+	if e.Domain, e.HostOnly, err = j.domainAndType(host, c.Domain); err != nil {
+		return nil, false, err
+	}
+	switch {
+	case c.MaxAge < 0:
+		return e, true, nil
+	case c.MaxAge > 0:
+		e.Expires, e.Persistent = now.Add(time.Duration(c.MaxAge)*time.Second), true
+	case !c.Expires.IsZero():
+		if !c.Expires.After(now) {
+			return e, true, nil
+		}
+		e.Expires, e.Persistent = c.Expires, true
+	}
+	return e, false, nil
+}
+
+var errIllegalCookieDomain = errors.New("httpdoc.CookieJar: illegal cookie domain attribute")
+
+func (j *CookieJar) domainAndType(host, domain string) (string, bool, error) {
+	if domain == "" {
+		return host, true, nil
+	}
+	if isIPHost(host) {
+		if host != domain {
+			return "", false, errIllegalCookieDomain
+		}
+		return host, true, nil
+	}
+	domain = normCookieDomain(domain)
+	if domain == "" || strings.HasSuffix(domain, ".") {
+		return "", false, errIllegalCookieDomain
+	}
+	// reject cookies for public suffixes (like "com" or "co.uk")
+	if j.psList != nil {
+		if ps := j.psList.PublicSuffix(domain); ps != "" && !hasDotSuffix(domain, ps) {
+			if host == domain {
+				return host, true, nil
+			}
+			return "", false, errIllegalCookieDomain
+		}
+	}
+	if host != domain && !hasDotSuffix(host, domain) {
+		return "", false, errIllegalCookieDomain
+	}
+	return domain, false, nil
+}
+
+func (e *jarEntry) expired(now time.Time) bool {
+	return e.Persistent && !e.Expires.After(now)
+}
+
+func (e *jarEntry) shouldSend(https bool, host, path string) bool {
+	return e.domainMatch(host) && e.pathMatch(path) && (https || !e.Secure)
+}
+
+func (e *jarEntry) domainMatch(host string) bool {
+	return e.Domain == host || !e.HostOnly && hasDotSuffix(host, e.Domain)
+}
+
+func (e *jarEntry) inDomain(domain string) bool {
+	return e.Domain == domain || hasDotSuffix(e.Domain, domain)
+}
+
+func (e *jarEntry) pathMatch(path string) bool {
+	if path == e.Path {
+		return true
+	}
+	if strings.HasPrefix(path, e.Path) {
+		return e.Path[len(e.Path)-1] == '/' || path[len(e.Path)] == '/'
+	}
+	return false
+}
+
+func (e *jarEntry) cookie() *http.Cookie {
+	c := &http.Cookie{
+		Name:     e.Name,
+		Value:    e.Value,
+		Domain:   e.Domain,
+		Path:     e.Path,
+		Secure:   e.Secure,
+		HttpOnly: e.HttpOnly,
+		SameSite: e.SameSite,
+	}
+	if e.Persistent {
+		c.Expires = e.Expires
+	}
+	return c
+}
+
+func canonicalHost(host string) (string, error) {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.Trim(host, "[]"), ".")
+	if isIPHost(host) {
+		return host, nil
+	}
+	return idna.Lookup.ToASCII(strings.ToLower(host))
+}
+
+func normCookieDomain(domain string) string {
+	domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+	if ascii, err := idna.Lookup.ToASCII(domain); err == nil {
+		return ascii
+	}
+	return domain
+}
+
+func isIPHost(host string) bool {
+	return net.ParseIP(host) != nil
+}
+
+func hasDotSuffix(s, suffix string) bool {
+	return len(s) > len(suffix) && s[len(s)-len(suffix)-1] == '.' && s[len(s)-len(suffix):] == suffix
+}
+
+func defaultCookiePath(path string) string {
+	if len(path) == 0 || path[0] != '/' {
+		return "/"
+	}
+	i := strings.LastIndex(path, "/")
+	if i == 0 {
+		return "/"
+	}
+	return path[:i]
+}
+
+//------------ Netscape cookies.txt format ------------
+
+// WriteNetscape writes cookies in Netscape cookies.txt format (used by curl, wget and browser extensions)
+func (j *CookieJar) WriteNetscape(w io.Writer) error {
+	j.mx.Lock()
+	entries := j.sortedEntries()
+	j.mx.Unlock()
+
+	bw := bufio.NewWriter(w)
+	bw.WriteString("# Netscape HTTP Cookie File\n\n")
+	now := time.Now()
+	for _, e := range entries {
+		if e.expired(now) {
+			continue
+		}
+		domain, includeSubdomains := e.Domain, "FALSE"
+		if !e.HostOnly {
+			domain, includeSubdomains = "."+domain, "TRUE"
+		}
+		if e.HttpOnly {
+			domain = "#HttpOnly_" + domain
+		}
+		var expires int64
+		if e.Persistent {
+			expires = e.Expires.Unix()
+		}
+		fmt.Fprintf(bw, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
+			domain, includeSubdomains, e.Path, strings.ToUpper(strconv.FormatBool(e.Secure)), expires, e.Name, e.Value)
+	}
+	return bw.Flush()
+}
+
+// ReadNetscape imports cookies from Netscape cookies.txt format
+func (j *CookieJar) ReadNetscape(r io.Reader) error {
+	now := time.Now()
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		httpOnly := strings.HasPrefix(line, "#HttpOnly_")
+		if httpOnly {
+			line = strings.TrimPrefix(line, "#HttpOnly_")
+		}
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		ff := strings.Split(line, "\t")
+		if len(ff) == 6 { // empty value
+			ff = append(ff, "")
+		}
+		if len(ff) != 7 {
+			return fmt.Errorf("httpdoc.CookieJar: invalid cookies.txt line %d", lineNum)
+		}
+		expires, err := strconv.ParseInt(ff[4], 10, 64)
+		if err != nil {
+			return fmt.Errorf("httpdoc.CookieJar: invalid expiration time in cookies.txt line %d", lineNum)
+		}
+		e := &jarEntry{
+			Domain:   normCookieDomain(ff[0]),
+			HostOnly: !strings.HasPrefix(ff[0], ".") && !strings.EqualFold(ff[1], "TRUE"),
+			Path:     ff[2],
+			Secure:   strings.EqualFold(ff[3], "TRUE"),
+			Name:     ff[5],
+			Value:    ff[6],
+			HttpOnly: httpOnly,
+			Creation: now,
+		}
+		if expires > 0 {
+			e.Expires, e.Persistent = time.Unix(expires, 0), true
+		}
+		j.importEntry(e, now)
+	}
+	return scanner.Err()
+}
+
+//------------ JSON format ------------
+
+// jsonCookie is format of cookies used by browser extensions (EditThisCookie, Cookie-Editor)
+type jsonCookie struct {
+	Domain         string  `json:"domain"`
+	ExpirationDate float64 `json:"expirationDate,omitempty"`
+	HostOnly       bool    `json:"hostOnly"`
+	HttpOnly       bool    `json:"httpOnly"`
+	Name           string  `json:"name"`
+	Path           string  `json:"path"`
+	SameSite       string  `json:"sameSite,omitempty"`
+	Secure         bool    `json:"secure"`
+	Session        bool    `json:"session"`
+	Value          string  `json:"value"`
+}
+
+var sameSiteNames = map[http.SameSite]string{
+	http.SameSiteLaxMode:    "lax",
+	http.SameSiteStrictMode: "strict",
+	http.SameSiteNoneMode:   "no_restriction",
+}
+
+// WriteJSON writes cookies as JSON-array (format of browser extensions like EditThisCookie)
+func (j *CookieJar) WriteJSON(w io.Writer) error {
+	j.mx.Lock()
+	entries := j.sortedEntries()
+	j.mx.Unlock()
+
+	now := time.Now()
+	cc := []jsonCookie{}
+	for _, e := range entries {
+		if e.expired(now) {
+			continue
+		}
+		c := jsonCookie{
+			Domain:   e.Domain,
+			HostOnly: e.HostOnly,
+			HttpOnly: e.HttpOnly,
+			Name:     e.Name,
+			Path:     e.Path,
+			SameSite: sameSiteNames[e.SameSite],
+			Secure:   e.Secure,
+			Session:  !e.Persistent,
+			Value:    e.Value,
+		}
+		if !e.HostOnly {
+			c.Domain = "." + c.Domain
+		}
+		if e.Persistent {
+			c.ExpirationDate = float64(e.Expires.UnixMilli()) / 1000
+		}
+		cc = append(cc, c)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(cc)
+}
+
+// ReadJSON imports cookies from JSON-array (format of browser extensions like EditThisCookie)
+func (j *CookieJar) ReadJSON(r io.Reader) error {
+	var cc []jsonCookie
+	if err := json.NewDecoder(r).Decode(&cc); err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, c := range cc {
+		e := &jarEntry{
+			Domain:   normCookieDomain(c.Domain),
+			HostOnly: c.HostOnly && !strings.HasPrefix(c.Domain, "."),
+			Path:     c.Path,
+			Secure:   c.Secure,
+			HttpOnly: c.HttpOnly,
+			Name:     c.Name,
+			Value:    c.Value,
+			Creation: now,
+		}
+		for mode, name := range sameSiteNames {
+			if strings.EqualFold(c.SameSite, name) {
+				e.SameSite = mode
+			}
+		}
+		if !c.Session && c.ExpirationDate > 0 {
+			e.Expires, e.Persistent = time.UnixMilli(int64(c.ExpirationDate*1000)), true
+		}
+		j.importEntry(e, now)
+	}
+	return nil
+}
+
+func (j *CookieJar) importEntry(e *jarEntry, now time.Time) {
+	if e.Name == "" || e.Domain == "" || e.expired(now) {
+		return
+	}
+	if e.Path == "" {
+		e.Path = "/"
+	}
+	j.mx.Lock()
+	defer j.mx.Unlock()
+	j.put(e)
+}
@@ -0,0 +1,92 @@
+package httpdoc
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCookieJar(t *testing.T) {
+	jar := NewCookieJar()
+	u, _ := url.Parse("https://www.example.co.uk/account/login")
+	jar.SetCookies(u, []*http.Cookie{
+		{Name: "sid", Value: "1", Domain: "example.co.uk", Path: "/", HttpOnly: true, Expires: time.Now().Add(time.Hour)},
+		{Name: "tmp", Value: "2"},
+		{Name: "tld", Value: "3", Domain: "co.uk"}, // must be rejected
+	})
+
+	other, _ := url.Parse("http://shop.example.co.uk/")
+	assert(t, cookiesStr(jar.Cookies(u)) == "tmp=2; sid=1")
+	assert(t, cookiesStr(jar.Cookies(other)) == "sid=1")
+	assert(t, strings.Join(jar.Domains(), ",") == "example.co.uk,www.example.co.uk")
+
+	var buf bytes.Buffer
+	assert(t, jar.WriteNetscape(&buf) == nil)
+	assert(t, strings.Contains(buf.String(), "#HttpOnly_.example.co.uk\tTRUE\t/\tFALSE\t"))
+	assert(t, strings.Contains(buf.String(), "www.example.co.uk\tFALSE\t/account\tFALSE\t0\ttmp\t2\n"))
+
+	jar2 := NewCookieJar()
+	assert(t, jar2.ReadNetscape(&buf) == nil)
+	assert(t, cookiesStr(jar2.Cookies(u)) == "tmp=2; sid=1")
+
+	buf.Reset()
+	assert(t, jar2.WriteJSON(&buf) == nil)
+	jar3 := NewCookieJar()
+	assert(t, jar3.ReadJSON(&buf) == nil)
+	assert(t, cookiesStr(jar3.Cookies(u)) == "tmp=2; sid=1")
+	assert(t, cookiesStr(jar3.Cookies(other)) == "sid=1")
+
+	assert(t, jar3.Delete("example.co.uk", "tmp") == 1)
+	assert(t, jar3.Expire("example.co.uk", "sid", time.Now().Add(-time.Second)) == 1)
+	assert(t, len(jar3.Cookies(u)) == 0)
+}
+
+func cookiesStr(cc []*http.Cookie) string {
+	var ss []string
+	for _, c := range cc {
+		ss = append(ss, c.String())
+	}
+	return strings.Join(ss, "; ")
+}
+
+func TestDocumentCookies(t *testing.T) {
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			http.SetCookie(w, &http.Cookie{Name: "sid", Value: "123", Path: "/", HttpOnly: true})
+			http.Redirect(w, r, "/home", http.StatusFound)
+		default:
+			w.Write([]byte(r.Header.Get("Cookie")))
+		}
+	})
+
+	doc := newDocument(srv.URL+"/login", NewClient(), nil)
+	doc.AddCookie("lang", "en")
+	assert(t, cookiesStr(doc.Cookies()) == "lang=en")
+	assert(t, doc.Load() == nil)
+	assert(t, doc.ContentStr() == "lang=en; sid=123")
+	assert(t, doc.Cookie("sid").HttpOnly)
+	assert(t, len(doc.ResponseCookies()) == 1)
+
+	doc = doc.NewDoc("/profile")
+	doc.SetCookie(&http.Cookie{Name: "lang", MaxAge: -1})
+	doc.SetCookies(map[string]string{"theme": "dark"})
+	assert(t, cookiesStr(doc.Cookies()) == "sid=123; theme=dark")
+	assert(t, doc.ContentStr() == "sid=123; theme=dark")
+
+	// cookies out of scope of url are not sent
+	doc = doc.NewDoc("/")
+	doc.SetCookie(&http.Cookie{Name: "admin", Value: "1", Path: "/admin"})
+	doc.SetCookie(&http.Cookie{Name: "other", Value: "1", Domain: "other.com"})
+	assert(t, doc.ContentStr() == "sid=123; theme=dark")
+	assert(t, doc.NewDoc("/admin/").ContentStr() == "admin=1; sid=123; theme=dark")
+
+	// client without cookie-jar
+	doc = newDocument(srv.URL+"/profile", &http.Client{}, nil)
+	doc.SetCookies(map[string]string{"a": "1"})
+	doc.AddCookie("b", "2")
+	assert(t, doc.ContentStr() == "a=1; b=2")
+}
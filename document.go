@@ -5,9 +5,11 @@ import (
 	"compress/flate"
 	"compress/gzip"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/dsnet/compress/brotli"
 	"github.com/goldic/js"
+	"golang.org/x/net/html"
 	"golang.org/x/text/encoding/htmlindex"
 	"golang.org/x/text/transform"
 	"io"
@@ -22,6 +24,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -32,7 +35,26 @@ type Document struct {
 	rawBody  []byte
 	Body     []byte
 
+	cacheMx     sync.Mutex
+	content     string     // cached string of Body (see ContentStr)
+	contentBody []byte     // Body converted to content
+	root        *html.Node // cached html-tree of Body (see htmlRoot)
+	rootBody    []byte     // Body parsed to root
+
 	multiParts []*multipartPart
+
+	session       *Session
+	middlewares   Middlewares
+	loading       bool
+	noMiddlewares bool
+	signers       []RequestSigner
+	attempts      int
+
+	proxyPool *ProxyPool
+	proxyAddr string
+
+	clientRedirects int              // max number of followed client-side redirects (see FollowClientRedirects)
+	history         []*http.Response // responses of pages with client-side redirects
 }
 
 type multipartPart struct {
@@ -51,7 +73,7 @@ var DefaultHeader = http.Header{
 }
 
 func NewDocument(url string) *Document {
-	return newDocument(url, DefaultClient)
+	return newDocument(url, DefaultClient, nil)
 }
 
 func LoadJSON(url string, v any) error {
@@ -66,12 +88,14 @@ func (d *Document) NewDoc(relURL string) *Document {
 	if err := d.Load(); err != nil {
 		panic(err)
 	}
-	org := d.Request.URL
+	org := d.URL()
 	u, err := url.Parse(relURL)
 	panicOnErr(err)
-	u = org.ResolveReference(u)
+	u = d.BaseURL().ResolveReference(u)
 
-	doc := newDocument(u.String(), d.Client)
+	doc := newDocument(u.String(), d.Client, d.session)
+	doc.proxyPool = d.proxyPool
+	doc.clientRedirects = d.clientRedirects
 	doc.SetHeader("Origin", org.Scheme+"://"+org.Host)
 	doc.SetHeader("Referer", org.String())
 	return doc
@@ -86,24 +110,22 @@ func (d *Document) NewAjax(relURL string) *Document {
 	return doc
 }
 
-func newDocument(urlStr string, client *http.Client) *Document {
+func newDocument(urlStr string, client *http.Client, session *Session) *Document {
 	req, err := http.NewRequest("GET", urlStr, nil)
 	panicOnErr(err)
 	req.PostForm = url.Values{}
+	req.Header = session.newRequestHeader()
 	if auth := req.URL.User; auth != nil {
 		if username := auth.Username(); username != "" {
 			password, _ := auth.Password()
 			req.SetBasicAuth(username, password)
 		}
 	}
-	for name, values := range DefaultHeader {
-		for _, value := range values {
-			req.Header.Add(name, value)
-		}
-	}
 	return &Document{
-		Client:  client,
-		Request: req,
+		Client:    client,
+		Request:   req,
+		session:   session,
+		proxyPool: session.getProxyPool(),
 	}
 }
 
@@ -134,6 +156,36 @@ func (d *Document) URL() *url.URL {
 	return d.Request.URL
 }
 
+// BaseURL returns url for resolving relative urls of document: <base href="..."> or url of loaded document
+func (d *Document) BaseURL() *url.URL {
+	if err := d.Load(); err != nil {
+		return d.URL()
+	}
+	docURL := d.URL()
+	if e := d.GetElementsByTagName("base").FilterByAttr("href").First(); e != nil {
+		if u, err := url.Parse(strings.TrimSpace(e.Attributes["href"])); err == nil {
+			return docURL.ResolveReference(u)
+		}
+	}
+	return docURL
+}
+
+// AbsURL resolves relative url (e.g. "/favicon.ico", "//cdn.com/img.png", "?page=2") against base url of document
+func (d *Document) AbsURL(relURL string) string {
+	return resolveURL(d.BaseURL(), relURL)
+}
+
+func resolveURL(base *url.URL, relURL string) string {
+	if relURL = strings.TrimSpace(relURL); relURL == "" || base == nil {
+		return relURL
+	}
+	u, err := url.Parse(relURL)
+	if err != nil {
+		return relURL
+	}
+	return base.ResolveReference(u).String()
+}
+
 func (d *Document) QueryParams() url.Values {
 	return d.URL().Query()
 }
@@ -194,8 +246,7 @@ func (d *Document) SetPOSTData(data []byte, contentType string) *Document {
 	}
 	d.Request.Method = "POST"
 	d.Request.Header.Set("Content-Type", contentType)
-	d.Request.Body = ioutil.NopCloser(bytes.NewBuffer(data))
-	d.Request.ContentLength = int64(len(data))
+	d.setRequestBody(data)
 	return d
 }
 
@@ -208,8 +259,9 @@ func (d *Document) SetJSON(v any) *Document {
 	return d
 }
 
+// SetCookies replaces cookies of request header by given cookies
 func (d *Document) SetCookies(cookies map[string]string) {
-	d.Request.Header.Set("Cookie", "")
+	d.Request.Header.Del("Cookie")
 	d.AddCookies(cookies)
 }
 
@@ -225,11 +277,110 @@ func (d *Document) AddCookie(name, value string) {
 	d.addCookies(&http.Cookie{Name: name, Value: value})
 }
 
+// SetCookie sets cookie with attributes (domain, path, expiration) to cookie-jar of client.
+// Cookie with negative MaxAge or past expiration time is removed.
+func (d *Document) SetCookie(c *http.Cookie) *Document {
+	d.addCookies(c)
+	return d
+}
+
+// addCookies puts cookies to client cookie-jar;
+// cookies without domain and path that are not accepted by jar (or if client has no jar) are set in request header
 func (d *Document) addCookies(cookies ...*http.Cookie) {
+	hasJar := d.Client != nil && d.Client.Jar != nil
+	var jarCookies []*http.Cookie
+	if hasJar {
+		d.Client.Jar.SetCookies(d.Request.URL, cookies)
+		jarCookies = d.Client.Jar.Cookies(d.Request.URL)
+	}
+	for _, c := range cookies {
+		d.removeHeaderCookie(c.Name)
+		expired := c.MaxAge < 0 || !c.Expires.IsZero() && c.Expires.Before(time.Now())
+		scoped := hasJar && (c.Domain != "" || c.Path != "") // out of scope of url if jar doesn't return it
+		if !expired && !scoped && !containsCookie(jarCookies, c) {
+			d.Request.AddCookie(&http.Cookie{Name: c.Name, Value: c.Value})
+		}
+	}
+}
+
+func (d *Document) removeHeaderCookie(name string) {
+	cc := d.Request.Cookies()
+	d.Request.Header.Del("Cookie")
+	for _, c := range cc {
+		if c.Name != name {
+			d.Request.AddCookie(c)
+		}
+	}
+}
+
+func containsCookie(cookies []*http.Cookie, c *http.Cookie) bool {
+	for _, v := range cookies {
+		if v.Name == c.Name && v.Value == c.Value {
+			return true
+		}
+	}
+	return false
+}
+
+// Cookies returns effective request cookies (from request header and cookie-jar).
+// For loaded document it returns cookies that have been sent with the last request.
+func (d *Document) Cookies() []*http.Cookie {
+	if d.Response != nil && d.Response.Request != nil {
+		return d.Response.Request.Cookies()
+	}
+	cookies := d.Request.Cookies()
+	if d.Client != nil && d.Client.Jar != nil {
+		for _, c := range d.Client.Jar.Cookies(d.Request.URL) {
+			if findCookie(cookies, c.Name) == nil {
+				cookies = append(cookies, c)
+			}
+		}
+	}
+	return cookies
+}
+
+// ResponseCookies returns cookies (with attributes) from Set-Cookie headers of response
+// and of all redirect responses preceding it
+func (d *Document) ResponseCookies() (cookies []*http.Cookie) {
+	if err := d.Load(); err != nil && d.Response == nil {
+		return nil
+	}
+	for resp := d.Response; resp != nil; {
+		cookies = append(resp.Cookies(), cookies...)
+		if resp.Request == nil {
+			break
+		}
+		resp = resp.Request.Response // previous response in redirect chain
+	}
+	return
+}
+
+// Cookie returns cookie by name. It looks up cookies set by response, then cookies of client jar for document url,
+// then request cookies. Returns nil if cookie is not found.
+func (d *Document) Cookie(name string) *http.Cookie {
+	if d.Loaded() {
+		cc := d.ResponseCookies()
+		for i := len(cc) - 1; i >= 0; i-- {
+			if cc[i].Name == name {
+				return cc[i]
+			}
+		}
+	}
+	if d.Client != nil && d.Client.Jar != nil {
+		if c := findCookie(d.Client.Jar.Cookies(d.URL()), name); c != nil {
+			return c
+		}
+	}
+	return findCookie(d.Cookies(), name)
+}
+
+func findCookie(cookies []*http.Cookie, name string) *http.Cookie {
 	for _, c := range cookies {
-		d.Request.AddCookie(c)
+		if c.Name == name {
+			return c
+		}
 	}
-	d.Client.Jar.SetCookies(d.URL(), cookies)
+	return nil
 }
 
 func (d *Document) IsMultipartRequest() bool {
@@ -313,6 +464,12 @@ func (d *Document) Load() error {
 	if d.Loaded() {
 		return nil
 	}
+	if d.loading {
+		return errors.New("httpdoc: document is not loaded yet")
+	}
+	d.loading = true
+	defer func() { d.loading = false }()
+
 	if d.IsMultipartRequest() {
 
 		pr, pw := io.Pipe()
@@ -347,46 +504,123 @@ func (d *Document) Load() error {
 
 	} else if len(d.Request.PostForm) > 0 {
 		// set request body
-		buf := bytes.NewBufferString(d.Request.PostForm.Encode())
+		data := []byte(d.Request.PostForm.Encode())
 		d.Request.Method = "POST"
 		d.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-		d.Request.Body = ioutil.NopCloser(buf)
-		d.Request.ContentLength = int64(buf.Len())
+		d.setRequestBody(data)
 	}
 	if d.Request.ContentLength > 0 {
 		d.Request.Header.Set("Content-Length", strconv.FormatInt(d.Request.ContentLength, 10))
 	}
-	if err := d.doRequest(); err != nil {
+	send := d.send
+	if !d.noMiddlewares {
+		send = func() error { return d.handleMiddlewares(d.send) }
+	}
+	if err := send(); err != nil {
 		return err
 	}
-	if charset := d.Charset(); charset != "utf-8" {
-		d.Body, _ = Iconv(d.rawBody, charset)
-	} else {
-		d.Body = d.rawBody
+	if !d.Loaded() {
+		return errors.New("httpdoc: request was not sent")
+	}
+	if status := d.Response.StatusCode; status >= 400 {
+		return fmt.Errorf("http-status-code %d", status)
 	}
+	if d.clientRedirects > 0 {
+		return d.followClientRedirects()
+	}
+	return nil
+}
 
-	// handle middleware
-	for _, fn := range middlewares {
-		err := func(fn middlewareFunc) (err error) {
-			defer func() {
-				if r := recover(); r != nil {
-					err = fmt.Errorf("httpdoc.Document.Load-PANIC: %v", r)
-				}
-			}()
-			return fn(d)
-		}(fn)
-		if err != nil {
+func (d *Document) setRequestBody(data []byte) {
+	d.Request.Body = ioutil.NopCloser(bytes.NewReader(data))
+	d.Request.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(data)), nil
+	}
+	d.Request.ContentLength = int64(len(data))
+}
+
+// requestBody reads whole request body and makes it re-readable
+func (d *Document) requestBody() ([]byte, error) {
+	req := d.Request
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	body := req.Body
+	if req.GetBody != nil {
+		var err error
+		if body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	data, err := ioutil.ReadAll(body)
+	body.Close()
+	if err != nil {
+		return nil, err
+	}
+	d.setRequestBody(data)
+	return data, nil
+}
+
+// send sends request and reads response. Repeated call resends request.
+func (d *Document) send() error {
+	if d.attempts++; d.attempts > 1 { // replay request
+		if d.Request.Body != nil && d.Request.Body != http.NoBody {
+			if d.Request.GetBody == nil {
+				return errors.New("httpdoc: request body can not be sent again")
+			}
+			body, err := d.Request.GetBody()
+			if err != nil {
+				return err
+			}
+			d.Request.Body = body
+		}
+		d.Response, d.rawBody, d.Body = nil, nil, nil
+	}
+	if d.proxyPool != nil {
+		if err := d.proxyPool.setProxy(d); err != nil {
 			return err
 		}
 	}
-	if status := d.Response.StatusCode; status >= 400 {
-		return fmt.Errorf("http-status-code %d", status)
+	if err := d.signRequest(); err != nil {
+		return err
 	}
-	return nil
+	start := time.Now()
+	err := d.doRequest()
+	if err == nil {
+		if charset := d.Charset(); charset != "utf-8" {
+			d.Body, _ = Iconv(d.rawBody, charset)
+		} else {
+			d.Body = d.rawBody
+		}
+	}
+	if d.proxyPool != nil {
+		d.proxyPool.report(d, err, time.Since(start))
+	}
+	return err
+}
+
+// SetResponse sets response and content of document without sending request.
+// It can be used by middleware to short-circuit request.
+func (d *Document) SetResponse(resp *http.Response, body []byte) *Document {
+	if resp.Request == nil {
+		resp.Request = d.Request
+	}
+	if resp.Header == nil {
+		resp.Header = http.Header{}
+	}
+	d.Response = resp
+	d.rawBody = body
+	if charset := d.Charset(); charset != "utf-8" {
+		d.Body, _ = Iconv(body, charset)
+	} else {
+		d.Body = body
+	}
+	return d
 }
 
 func (d *Document) doRequest() (err error) {
-	if d.Response, err = d.Client.Do(d.Request); err != nil {
+	// the copy is sent since client adds cookies of jar to header of request, which would be duplicated on replay
+	if d.Response, err = d.Client.Do(d.Request.Clone(d.Request.Context())); err != nil {
 		return
 	}
 	defer d.Response.Body.Close()
@@ -488,8 +722,20 @@ RESPONSE:
 	return d
 }
 
+// ContentStr returns body of document as string. String is cached until Body is replaced.
 func (d *Document) ContentStr() string {
-	return string(d.Content())
+	body := d.Content()
+	d.cacheMx.Lock()
+	defer d.cacheMx.Unlock()
+	if !sameBytes(body, d.contentBody) {
+		d.content, d.contentBody = string(body), body
+	}
+	return d.content
+}
+
+// sameBytes reports whether slices refer to the same memory
+func sameBytes(a, b []byte) bool {
+	return len(a) == len(b) && (len(a) == 0 || &a[0] == &b[0])
 }
 
 func (d *Document) Content() []byte {
@@ -520,7 +766,7 @@ func (d *Document) Match(regExp any) []string {
 }
 
 func (d *Document) Submatch(regExp any, submatchNum int) string {
-	if submatches := d.Match(regExp); len(submatches) > 0 {
+	if submatches := d.Match(regExp); submatchNum >= 0 && submatchNum < len(submatches) {
 		return submatches[submatchNum]
 	}
 	return ""
@@ -534,8 +780,13 @@ func (d *Document) SubmatchAll(regExp any) [][]string {
 	return normRe(regExp).FindAllStringSubmatch(d.ContentStr(), -1)
 }
 
+// AllSubmatches returns submatches with number submatchNum of all matches (nil if there is no such submatch)
 func (d *Document) AllSubmatches(regExp any, submatchNum int) (submatches []string) {
-	for _, ss := range d.SubmatchAll(regExp) {
+	re := normRe(regExp)
+	if submatchNum < 0 || submatchNum > re.NumSubexp() {
+		return nil
+	}
+	for _, ss := range re.FindAllStringSubmatch(d.ContentStr(), -1) {
 		submatches = append(submatches, ss[submatchNum])
 	}
 	return
@@ -610,30 +861,30 @@ func (d *Document) MetaCSRFToken() string {
 	return ""
 }
 
-// MetaIcon gets image-url from meta-info for html-document
+// MetaIcon gets absolute image-url of icon from meta-info for html-document (or url of /favicon.ico)
 func (d *Document) MetaIcon() string {
 	linkTags := d.GetElementsByTagName("link").FilterByAttr("href")
 	for _, relVal := range []string{"icon", "shortcut icon", "apple-touch-icon"} {
 		if tags := linkTags.FilterByAttrValue("rel", relVal); len(tags) > 0 {
 			if tags := tags.FilterByAttrValue("type", "image/ico"); len(tags) > 0 {
-				return tags[0].Attributes["href"]
+				return tags[0].AbsURL("href")
 			}
-			return tags[0].Attributes["href"]
+			return tags[0].AbsURL("href")
 		}
 	}
-	return ""
+	return d.AbsURL("/favicon.ico")
 }
 
-// MetaImage gets image-url from meta-info for html-document
+// MetaImage gets absolute image-url from meta-info for html-document
 func (d *Document) MetaImage() string {
 	if tag := d.GetElementsByTagName("link").FilterByAttrValue("rel", "image").FilterByAttr("href").First(); tag != nil {
-		return tag.Attributes["href"]
+		return tag.AbsURL("href")
 	}
 	if tag := d.GetElementsByTagName("link").FilterByAttrValue("rel", "image_src").FilterByAttr("href").First(); tag != nil {
-		return tag.Attributes["href"]
+		return tag.AbsURL("href")
 	}
 	if tag := d.GetElementsByTagName("meta").FilterByAttrValue("property", "og:image").FilterByAttr("content").First(); tag != nil {
-		return tag.Attributes["content"]
+		return tag.AbsURL("content")
 	}
 	return ""
 }
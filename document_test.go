@@ -1,6 +1,10 @@
 package httpdoc
 
-import "testing"
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
 
 func TestHelloWorld(t *testing.T) {
 
@@ -45,6 +49,32 @@ func TestSubmitForm(t *testing.T) {
 	assert(t, "sha256 - Search Results - Go Packages" == doc.Title())
 }
 
+func TestAbsURL(t *testing.T) {
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/blog/post":
+			w.Write([]byte(`<head><base href="/static/"><link rel="image_src" href="img.png"></head>
+				<a href="page.html">page</a> <img src="//cdn.example.com/a.png"> <form method="post"></form>`))
+		default:
+			w.Write([]byte(`<a href="../about">about</a>`))
+		}
+	})
+
+	doc := newDocument(srv.URL+"/blog/post", NewClient(), nil)
+	assert(t, doc.AbsURL("x.js") == srv.URL+"/static/x.js")
+	assert(t, doc.Links().First().AbsURL("href") == srv.URL+"/static/page.html")
+	assert(t, doc.Images().First().AbsURL("src") == "http://cdn.example.com/a.png")
+	assert(t, doc.Images().First().AbsURL("alt") == "")
+	assert(t, doc.MetaImage() == srv.URL+"/static/img.png")
+	assert(t, doc.MetaIcon() == srv.URL+"/favicon.ico")
+	assert(t, doc.Links().First().Doc().URL().String() == srv.URL+"/static/page.html")
+	assert(t, doc.Forms().First().Doc().URL().String() == srv.URL+"/blog/post")
+
+	doc = newDocument(srv.URL+"/blog/", NewClient(), nil)
+	assert(t, doc.NewDoc("post").URL().String() == srv.URL+"/blog/post")
+	assert(t, doc.Links().First().AbsURL("href") == srv.URL+"/about")
+}
+
 func _TestGoogleTranslateWebSite(t *testing.T) {
 	site, from, to := "https://go.dev/", "en", "ru"
 
@@ -63,3 +93,16 @@ func assert(t *testing.T, ok bool) {
 		t.Fail()
 	}
 }
+
+// newTestServer starts http-server closed at the end of test; content is body of all responses or handler of requests
+func newTestServer(t *testing.T, content any) *httptest.Server {
+	h, ok := content.(func(http.ResponseWriter, *http.Request))
+	if !ok {
+		h = func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(content.(string)))
+		}
+	}
+	srv := httptest.NewServer(http.HandlerFunc(h))
+	t.Cleanup(srv.Close)
+	return srv
+}
@@ -0,0 +1,237 @@
+package httpdoc
+
+import (
+	"fmt"
+	"iter"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/cascadia"
+	"golang.org/x/net/html"
+)
+
+// FrameTreeConcurrency is max number of concurrent requests of frames of FrameTree
+var FrameTreeConcurrency = 8
+
+// referrerPolicies are referrer policies with legacy values of <meta name="referrer">
+var referrerPolicies = map[string]string{
+	"no-referrer": "no-referrer", "no-referrer-when-downgrade": "no-referrer-when-downgrade", "same-origin": "same-origin",
+	"origin": "origin", "strict-origin": "strict-origin", "origin-when-cross-origin": "origin-when-cross-origin",
+	"strict-origin-when-cross-origin": "strict-origin-when-cross-origin", "unsafe-url": "unsafe-url",
+	"never": "no-referrer", "default": "strict-origin-when-cross-origin", "always": "unsafe-url",
+	"origin-when-crossorigin": "origin-when-cross-origin",
+}
+
+// Frame is node of tree of frames of html-document (see FrameTree)
+type Frame struct {
+	Document *Document    // document of frame (page itself for root of tree)
+	Element  *HTMLElement // <iframe> or <frame> of parent document (nil for root)
+	Parent   *Frame       // parent frame (nil for root)
+	Children []*Frame     // nested frames in order of parent document
+	Err      error        // error of loading of frame
+}
+
+// FrameTree loads document and its frames (<iframe>, <frame>) concurrently up to depth levels of nesting.
+// Errors of frames are set to their Err; frames without http(s)-url have empty documents.
+func (d *Document) FrameTree(depth int) (*Frame, error) {
+	if err := d.Load(); err != nil {
+		return nil, err
+	}
+	root := &Frame{Document: d}
+	root.loadFrames(depth, make(chan struct{}, max(FrameTreeConcurrency, 1)))
+	return root, nil
+}
+
+// All returns iterator over frame and all its nested frames (in depth-first order)
+func (f *Frame) All() iter.Seq[*Frame] {
+	return func(yield func(*Frame) bool) {
+		f.walk(yield)
+	}
+}
+
+func (f *Frame) walk(yield func(*Frame) bool) bool {
+	if !yield(f) {
+		return false
+	}
+	for _, c := range f.Children {
+		if !c.walk(yield) {
+			return false
+		}
+	}
+	return true
+}
+
+// Documents returns successfully loaded documents of frame and of all its nested frames
+func (f *Frame) Documents() (docs []*Document) {
+	for fr := range f.All() {
+		if fr.Err == nil {
+			docs = append(docs, fr.Document)
+		}
+	}
+	return
+}
+
+// Find gets html-elements matching css-selector in documents of all frames of tree.
+// Document of element is document of frame where element is found.
+func (f *Frame) Find(selector string) (res HTMLElements) {
+	sel, err := cascadia.Compile(selector)
+	if err != nil {
+		return nil
+	}
+	for _, doc := range f.Documents() {
+		res = append(res, findHTMLElements(doc, doc.htmlRoot(), sel)...)
+	}
+	return
+}
+
+// loadFrames makes children of frame and loads them concurrently; sem limits number of concurrent requests
+func (f *Frame) loadFrames(depth int, sem chan struct{}) {
+	d := f.Document
+	if depth <= 0 || f.Err != nil {
+		return
+	}
+	if ct := d.ContentType(); ct != "" && !strings.Contains(ct, "html") {
+		return
+	}
+	for _, e := range d.Find("iframe, frame") {
+		f.Children = append(f.Children, f.newFrame(e))
+	}
+	var wg sync.WaitGroup
+	for _, c := range f.Children {
+		if c.Err != nil {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			c.Err = c.Document.Load()
+			<-sem
+			if c.Err == nil {
+				c.loadFrames(depth-1, sem)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// newFrame makes child frame of html-element <iframe> or <frame>
+func (f *Frame) newFrame(e *HTMLElement) *Frame {
+	d := f.Document
+	c := &Frame{Element: e, Parent: f}
+	if srcdoc, ok := e.Attributes["srcdoc"]; ok && e.TagName == "iframe" {
+		c.Document = d.NewDoc(d.URL().String()).SetResponse(newHTMLResponse(), []byte(srcdoc))
+		return c
+	}
+	src := strings.TrimSpace(e.Attributes["src"])
+	u, err := url.Parse(src)
+	if err == nil {
+		u = d.BaseURL().ResolveReference(u)
+	}
+	if err != nil || src == "" || u.Scheme != "http" && u.Scheme != "https" {
+		c.Document = d.NewDoc("about:blank").SetResponse(newHTMLResponse(), nil)
+		c.Err = err
+		return c
+	}
+	c.Document = d.NewDoc(u.String())
+	policy := referrerPolicies[strings.ToLower(strings.TrimSpace(e.Attributes["referrerpolicy"]))]
+	if policy == "" {
+		policy = f.referrerPolicy()
+	}
+	setFrameReferrer(c.Document, d.URL(), policy)
+	for p := f; p != nil; p = p.Parent {
+		if visitedKey(p.Document.URL()) == visitedKey(u) {
+			c.Err = fmt.Errorf("httpdoc: recursive frame %s", u)
+			break
+		}
+	}
+	return c
+}
+
+// referrerPolicy returns referrer policy of document of frame by <meta name="referrer"> or header Referrer-Policy.
+// Frame with srcdoc inherits policy of parent.
+func (f *Frame) referrerPolicy() (policy string) {
+	d := f.Document
+	walkHTML(d.htmlRoot(), func(n *html.Node) {
+		if n.Data == "meta" && strings.EqualFold(htmlAttr(n, "name"), "referrer") {
+			if p := referrerPolicies[strings.ToLower(strings.TrimSpace(htmlAttr(n, "content")))]; p != "" {
+				policy = p
+			}
+		}
+	})
+	if policy != "" {
+		return
+	}
+	for _, v := range strings.Split(d.Response.Header.Get("Referrer-Policy"), ",") {
+		if v = strings.ToLower(strings.TrimSpace(v)); v != "" && referrerPolicies[v] == v { // the last known policy is used
+			policy = v
+		}
+	}
+	if policy == "" && f.Parent != nil {
+		if _, srcdoc := f.Element.Attributes["srcdoc"]; srcdoc {
+			return f.Parent.referrerPolicy()
+		}
+	}
+	return
+}
+
+func newHTMLResponse() *http.Response {
+	return &http.Response{
+		Status:     "200 OK",
+		StatusCode: http.StatusOK,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{"Content-Type": {"text/html; charset=utf-8"}},
+	}
+}
+
+// setFrameReferrer sets header Referer of request of frame by referrer policy (https://www.w3.org/TR/referrer-policy/)
+func setFrameReferrer(doc *Document, from *url.URL, policy string) {
+	to := doc.URL()
+	ref := *from
+	ref.User, ref.Fragment, ref.RawFragment = nil, "", ""
+	full := ref.String()
+	origin := ref.Scheme + "://" + ref.Host + "/"
+	sameOrigin := strings.EqualFold(from.Scheme, to.Scheme) && strings.EqualFold(from.Host, to.Host)
+	downgrade := from.Scheme == "https" && to.Scheme != "https"
+
+	var referer string
+	switch policy {
+	case "no-referrer":
+	case "unsafe-url":
+		referer = full
+	case "origin":
+		referer = origin
+	case "same-origin":
+		if sameOrigin {
+			referer = full
+		}
+	case "origin-when-cross-origin":
+		if referer = origin; sameOrigin {
+			referer = full
+		}
+	case "strict-origin":
+		if !downgrade {
+			referer = origin
+		}
+	case "no-referrer-when-downgrade":
+		if !downgrade {
+			referer = full
+		}
+	default: // strict-origin-when-cross-origin
+		if sameOrigin {
+			referer = full
+		} else if !downgrade {
+			referer = origin
+		}
+	}
+	doc.Request.Header.Del("Origin")
+	if referer != "" {
+		doc.SetHeader("Referer", referer)
+	} else {
+		doc.Request.Header.Del("Referer")
+	}
+}
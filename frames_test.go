@@ -0,0 +1,111 @@
+package httpdoc
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDocument_FrameTree(t *testing.T) {
+	var other *httptest.Server
+	var active, maxActive atomic.Int32
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprintf(w, `<html><body><p class="x">top</p>
+				<iframe src="/a#frag"></iframe>
+				<iframe srcdoc="<p class=x>inline</p><iframe src='/b'></iframe>"></iframe>
+				<iframe></iframe>
+				<iframe src="%s/ext"></iframe>
+				<iframe src="/missing"></iframe>
+			</body></html>`, other.URL)
+		case "/a":
+			fmt.Fprintf(w, `<p class="x">a %s</p><iframe src="/"></iframe>`, r.Referer())
+		case "/b":
+			fmt.Fprintf(w, `<p class="x">b %s</p><iframe src="/c"></iframe>`, r.Referer())
+		case "/c":
+			fmt.Fprint(w, `<p class="x">c</p>`)
+		case "/header-policy":
+			w.Header().Set("Referrer-Policy", "unsafe-url, no-referrer")
+			fmt.Fprint(w, `<iframe srcdoc="<iframe src='/a'></iframe>"></iframe>`)
+		case "/meta-policy":
+			fmt.Fprint(w, `<html><meta name="referrer" content="origin"><iframe src="/a"></iframe><iframe src="/b" referrerpolicy="unsafe-url"></iframe>`)
+		case "/many":
+			for i := 0; i < 30; i++ {
+				fmt.Fprintf(w, `<iframe src="/slow?%d"></iframe>`, i)
+			}
+		case "/slow":
+			if n := active.Add(1); n > maxActive.Load() {
+				maxActive.Store(n)
+			}
+			time.Sleep(5 * time.Millisecond)
+			active.Add(-1)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	other = newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<p class="x">ext %s</p>`, r.Referer())
+	})
+
+	tree, err := newDocument(srv.URL+"/", NewClient(), nil).FrameTree(2)
+	assert(t, err == nil)
+	assert(t, len(tree.Children) == 5)
+
+	a, inline, blank, ext, missing := tree.Children[0], tree.Children[1], tree.Children[2], tree.Children[3], tree.Children[4]
+	assert(t, a.Err == nil && a.Parent == tree && a.Element.Attributes["src"] == "/a#frag")
+	assert(t, len(a.Children) == 1 && a.Children[0].Err != nil) // recursive frame
+	assert(t, inline.Err == nil && len(inline.Children) == 1 && len(inline.Children[0].Children) == 0)
+	assert(t, blank.Err == nil && blank.Document.URL().String() == "about:blank")
+	assert(t, ext.Err == nil && missing.Err != nil)
+
+	var texts []string
+	for _, e := range tree.Find("p.x") {
+		texts = append(texts, e.InnerText())
+	}
+	assert(t, strings.Join(texts, "|") == strings.Join([]string{
+		"top",
+		"a " + srv.URL + "/",
+		"inline",
+		"b " + srv.URL + "/",
+		"ext " + srv.URL + "/",
+	}, "|"))
+	assert(t, tree.Find("p.x")[1].Document == a.Document)
+	assert(t, len(tree.Documents()) == 6)
+
+	// referrer policy of parent document
+	tree, _ = newDocument(srv.URL+"/header-policy", NewClient(), nil).FrameTree(2)
+	assert(t, tree.Find("p.x").First().InnerText() == "a")
+	tree, _ = newDocument(srv.URL+"/meta-policy", NewClient(), nil).FrameTree(1)
+	texts = nil
+	for _, e := range tree.Find("p.x") {
+		texts = append(texts, e.InnerText())
+	}
+	assert(t, strings.Join(texts, "|") == "a "+srv.URL+"/|b "+srv.URL+"/meta-policy")
+
+	// limit of concurrent requests
+	tree, _ = newDocument(srv.URL+"/many", NewClient(), nil).FrameTree(1)
+	assert(t, len(tree.Documents()) == 31)
+	assert(t, maxActive.Load() > 1 && maxActive.Load() <= int32(FrameTreeConcurrency))
+	assert(t, tree.Find("p[") == nil)
+}
+
+func TestSetFrameReferrer(t *testing.T) {
+	for _, c := range []struct{ from, to, policy, referer string }{
+		{"https://a.com/p?q=1#x", "https://a.com/f", "", "https://a.com/p?q=1"},
+		{"https://a.com/p?q=1", "https://b.com/f", "", "https://a.com/"},
+		{"https://a.com/p", "http://b.com/f", "", ""},
+		{"https://a.com/p", "https://a.com/f", "no-referrer", ""},
+		{"https://a.com/p", "https://b.com/f", "unsafe-url", "https://a.com/p"},
+		{"https://a.com/p", "https://b.com/f", "same-origin", ""},
+		{"http://a.com/p", "http://b.com/f", "no-referrer-when-downgrade", "http://a.com/p"},
+	} {
+		doc := NewDocument(c.to)
+		setFrameReferrer(doc, NewDocument(c.from).URL(), c.policy)
+		assert(t, doc.Request.Header.Get("Referer") == c.referer)
+	}
+}
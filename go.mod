@@ -5,9 +5,15 @@ go 1.23.0
 toolchain go1.23.4
 
 require (
+	github.com/andybalholm/cascadia v1.3.3
+	github.com/antchfx/htmlquery v1.3.5
+	github.com/antchfx/xpath v1.3.5
 	github.com/denisskin/gosync v0.0.0-20190607074426-d8838767369b
 	github.com/dsnet/compress v0.0.1
 	github.com/goldic/js v0.0.0-20250304115818-34e6f583f631
 	golang.org/x/net v0.37.0
 	golang.org/x/text v0.23.0
+	gopkg.in/yaml.v3 v3.0.1
 )
+
+require github.com/golang/groupcache v0.0.0-20210331224755-41bb18bfe9da // indirect
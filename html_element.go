@@ -14,15 +14,12 @@ type HTMLElement struct {
 	TagName    string
 	Attributes map[string]string
 	InnerHTML  string
+
+	node *html.Node // parsed node (for elements found by css-selector)
 }
 
 var (
-	reTag      = regexp.MustCompile(`</?[a-zA-Z0-9\-]+[^>]*>`)
-	reSpec     = regexp.MustCompile(`(?si:<!--.*?-->|<[?!].*?>|<style.*?</style>|<script.*?</script>)`)
 	reSpace    = regexp.MustCompile(`\s+`)
-	reBr       = regexp.MustCompile(`<(?i:br|p)[^<>]*/?>`)
-	reLi       = regexp.MustCompile(`<(?i:li)[^<>]*>`)
-	reNewLine  = regexp.MustCompile(`(?s:\n+)`)
 	reInputs   = regexp.MustCompile(`<(?i:input|textarea|select|button)\b([^>]*)>`)
 	reTagAttrs = regexp.MustCompile(`\s([a-zA-Z0-9\-]+)=('[^']*'|"[^"]*")`)
 )
@@ -30,10 +27,15 @@ var (
 var isSingleTag = map[string]bool{
 	"meta":   true,
 	"link":   true,
+	"base":   true,
 	"br":     true,
 	"hr":     true,
+	"img":    true,
 	"input":  true,
 	"option": true,
+	"source": true,
+	"embed":  true,
+	"area":   true,
 }
 
 func getElementsByTagName(d *Document, name string) (ee HTMLElements) {
@@ -73,21 +75,13 @@ func parseTagAttrs(s string) map[string]string {
 	return attrs
 }
 
-func HtmlToText(s string) string {
-	s = reSpec.ReplaceAllString(s, "")
-	s = reSpace.ReplaceAllString(s, " ")
-	s = reBr.ReplaceAllString(s, "\n")
-	s = reLi.ReplaceAllString(s, "\n- ")
-	s = reTag.ReplaceAllString(s, "")
-	s = reNewLine.ReplaceAllString(s, "\n")
-	s = strings.TrimSpace(html.UnescapeString(s))
-	return s
-}
-
 func (e *HTMLElement) InnerText() string {
 	if e == nil {
 		return ""
 	}
+	if n := e.htmlNode(); n != nil {
+		return htmlNodeToText(n)
+	}
 	return HtmlToText(e.InnerHTML)
 }
 
@@ -113,10 +107,22 @@ func (e *HTMLElement) FormParams() url.Values {
 	return vals
 }
 
+// AbsURL returns absolute url from attribute value (resolved against <base href> or url of document)
+func (e *HTMLElement) AbsURL(attrName string) string {
+	if e == nil {
+		return ""
+	}
+	return e.Document.AbsURL(e.Attributes[attrName])
+}
+
 func (e *HTMLElement) Doc() *Document {
 	switch e.TagName {
 	case "form":
-		doc := e.Document.NewDoc(e.Attributes["action"])
+		action := e.Attributes["action"]
+		if strings.TrimSpace(action) == "" { // form without action is submitted to document url (not to <base href>)
+			action = e.Document.URL().String()
+		}
+		doc := e.Document.NewDoc(action)
 		if method := e.Attributes["method"]; method != "" {
 			doc.Request.Method = strings.ToUpper(method)
 		}
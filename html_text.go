@@ -0,0 +1,334 @@
+package httpdoc
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// TextOptions are options of html to text rendering (see HtmlToTextWithOptions)
+type TextOptions struct {
+	Width         int  // max width of lines; 0 - don't wrap lines
+	LinkFootnotes bool // add footnote marks [N] after links and list of link urls at the end of text
+	AlignTables   bool // align table columns with spaces; by default cells are separated by tabs
+}
+
+// textSkippedTags are tags which content is not rendered
+var textSkippedTags = map[string]bool{
+	"head": true, "title": true, "meta": true, "link": true, "script": true, "style": true, "noscript": true,
+	"template": true, "svg": true, "canvas": true, "iframe": true, "object": true,
+}
+
+// textBlockTags are block tags; value is number of line breaks around block
+var textBlockTags = map[string]int{
+	"p": 2, "h1": 2, "h2": 2, "h3": 2, "h4": 2, "h5": 2, "h6": 2, "blockquote": 2, "pre": 2, "table": 2, "hr": 2,
+	"figure": 2, "dl": 2, "ul": 2, "ol": 2,
+	"div": 1, "section": 1, "article": 1, "main": 1, "header": 1, "footer": 1, "nav": 1, "aside": 1, "address": 1,
+	"form": 1, "fieldset": 1, "details": 1, "summary": 1, "dialog": 1, "figcaption": 1, "hgroup": 1, "caption": 1,
+	"li": 1, "dt": 1, "dd": 1, "tr": 1, "body": 1, "html": 1,
+}
+
+// HtmlToText renders html as plain text: blocks are separated by new lines, paragraphs by empty lines,
+// list items are prefixed by "- " (or by number for ordered lists), table cells are separated by tabs.
+func HtmlToText(s string) string {
+	return strings.TrimSpace(HtmlToTextWithOptions(s, TextOptions{}))
+}
+
+// HtmlToTextWithOptions renders html as plain text with options
+func HtmlToTextWithOptions(s string, opts TextOptions) string {
+	context := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(s), context)
+	if err != nil {
+		return ""
+	}
+	w := &textWriter{opts: opts, links: new([]string)}
+	for _, n := range nodes {
+		w.node(n)
+	}
+	text := w.String()
+	if links := *w.links; len(links) > 0 {
+		var sb strings.Builder
+		sb.WriteString(text)
+		sb.WriteString("\n")
+		for i, href := range links {
+			sb.WriteString("\n[" + strconv.Itoa(i+1) + "] " + href)
+		}
+		text = sb.String()
+	}
+	return text
+}
+
+// htmlNodeToText renders node as plain text (content of skipped tags like <script> is rendered as is)
+func htmlNodeToText(n *html.Node) string {
+	w := &textWriter{links: new([]string)}
+	if textSkippedTags[n.Data] {
+		w.children(n)
+	} else {
+		w.node(n)
+	}
+	return strings.TrimSpace(w.String())
+}
+
+// htmlCellText renders content of node as single line of text
+func htmlCellText(n *html.Node) string {
+	w := &textWriter{links: new([]string)}
+	w.children(n)
+	return strings.Join(strings.Fields(w.String()), " ")
+}
+
+type textWriter struct {
+	opts      TextOptions
+	sb        strings.Builder
+	inLine    bool      // prefix of current line is written
+	col       int       // current column (in runes)
+	indent    string    // indent of lines of current block
+	marker    string    // marker of list item to write instead of end of indent
+	breaks    int       // pending line breaks
+	space     bool      // pending space between words
+	listDepth int       // depth of nested lists
+	links     *[]string // urls of footnotes
+}
+
+func (w *textWriter) String() string {
+	return strings.TrimRight(w.sb.String(), " \n")
+}
+
+// lineBreak ends current block; n = 1 starts new line, n = 2 adds empty line
+func (w *textWriter) lineBreak(n int) {
+	if w.sb.Len() > 0 {
+		w.breaks = max(w.breaks, n)
+	}
+	w.space = false
+}
+
+func (w *textWriter) newLine() {
+	w.sb.WriteByte('\n')
+	w.inLine, w.col = false, 0
+}
+
+// startLine writes pending line breaks and prefix of new line
+func (w *textWriter) startLine() {
+	if w.breaks > 0 {
+		n := w.breaks
+		if !w.inLine {
+			n--
+		}
+		w.sb.WriteString(strings.Repeat("\n", n))
+		w.inLine, w.col, w.breaks = false, 0, 0
+	}
+	if !w.inLine {
+		prefix := w.indent
+		if w.marker != "" {
+			prefix = prefix[:len(prefix)-len(w.marker)] + w.marker
+			w.marker = ""
+		}
+		w.sb.WriteString(prefix)
+		w.inLine, w.col, w.space = true, utf8.RuneCountInString(prefix), false
+	}
+}
+
+func (w *textWriter) word(word string) {
+	w.startLine()
+	n := utf8.RuneCountInString(word)
+	if w.space && w.col > len(w.indent) {
+		if w.opts.Width > 0 && w.col+1+n > w.opts.Width {
+			w.newLine()
+			w.startLine()
+		} else {
+			w.sb.WriteByte(' ')
+			w.col++
+		}
+	}
+	w.sb.WriteString(word)
+	w.col += n
+	w.space = false
+}
+
+func (w *textWriter) text(s string) {
+	if s == "" {
+		return
+	}
+	if isSpace(s[0]) && w.inLine {
+		w.space = true
+	}
+	for i, word := range strings.Fields(s) {
+		if i > 0 {
+			w.space = true
+		}
+		w.word(word)
+	}
+	if isSpace(s[len(s)-1]) && w.inLine {
+		w.space = true
+	}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+// preformatted writes lines of text as is
+func (w *textWriter) preformatted(s string) {
+	for i, line := range strings.Split(strings.TrimSuffix(s, "\n"), "\n") {
+		if i > 0 {
+			w.newLine()
+		}
+		w.startLine()
+		w.sb.WriteString(line)
+		w.col += utf8.RuneCountInString(line)
+	}
+}
+
+func (w *textWriter) children(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.node(c)
+	}
+}
+
+func (w *textWriter) node(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		w.text(n.Data)
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+	if textSkippedTags[n.Data] {
+		return
+	}
+	breaks := textBlockTags[n.Data]
+	if (n.Data == "ul" || n.Data == "ol") && w.listDepth > 0 {
+		breaks = 1 // nested list
+	}
+	if breaks > 0 {
+		w.lineBreak(breaks)
+	}
+	switch n.Data {
+	case "br":
+		if w.breaks > 0 {
+			w.breaks++
+		} else if w.sb.Len() > 0 {
+			w.newLine()
+		}
+		w.space = false
+
+	case "img":
+		if alt := strings.TrimSpace(htmlAttr(n, "alt")); alt != "" {
+			w.text(" " + alt + " ")
+		}
+
+	case "pre":
+		w.preformatted(htmlNodeRawText(n))
+
+	case "ul", "ol":
+		w.listDepth++
+		defer func() { w.listDepth-- }()
+		num := 1
+		if start, err := strconv.Atoi(htmlAttr(n, "start")); err == nil {
+			num = start
+		}
+		for li := n.FirstChild; li != nil; li = li.NextSibling {
+			if li.Type != html.ElementNode || li.Data != "li" {
+				w.node(li)
+				continue
+			}
+			marker := "- "
+			if n.Data == "ol" {
+				marker = strconv.Itoa(num) + ". "
+				num++
+			}
+			indent := w.indent
+			w.lineBreak(1)
+			w.indent, w.marker = indent+strings.Repeat(" ", len(marker)), marker
+			w.children(li)
+			w.indent, w.marker = indent, ""
+		}
+
+	case "blockquote", "dd":
+		indent := w.indent
+		w.indent += "  "
+		w.children(n)
+		w.indent = indent
+
+	case "table", "thead", "tbody", "tfoot", "tr": // parts of table are rendered as table (e.g. InnerText of row)
+		w.table(n)
+
+	case "a":
+		w.children(n)
+		if href := strings.TrimSpace(htmlAttr(n, "href")); w.opts.LinkFootnotes && href != "" &&
+			!strings.HasPrefix(href, "#") && !strings.HasPrefix(strings.ToLower(href), "javascript:") {
+			*w.links = append(*w.links, href)
+			w.word("[" + strconv.Itoa(len(*w.links)) + "]")
+		}
+
+	default:
+		w.children(n)
+	}
+	if breaks > 0 {
+		w.lineBreak(breaks)
+	}
+}
+
+func (w *textWriter) table(n *html.Node) {
+	var rows [][]string
+	addRow := func(tr *html.Node) {
+		var row []string
+		for td := tr.FirstChild; td != nil; td = td.NextSibling {
+			if td.Type == html.ElementNode && (td.Data == "td" || td.Data == "th") {
+				cw := &textWriter{opts: TextOptions{LinkFootnotes: w.opts.LinkFootnotes}, links: w.links}
+				cw.children(td)
+				row = append(row, strings.Join(strings.Fields(cw.String()), " "))
+			}
+		}
+		rows = append(rows, row)
+	}
+	var addRows func(n *html.Node)
+	addRows = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			switch c.Data {
+			case "thead", "tbody", "tfoot":
+				addRows(c)
+			case "tr":
+				addRow(c)
+			case "caption":
+				w.node(c)
+				w.lineBreak(1)
+			}
+		}
+	}
+	if n.Data == "tr" {
+		addRow(n)
+	} else {
+		addRows(n)
+	}
+
+	var widths []int
+	for _, row := range rows {
+		for i, cell := range row {
+			if i >= len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+	for _, row := range rows {
+		var line string
+		if w.opts.AlignTables {
+			for i, cell := range row {
+				if i < len(row)-1 {
+					cell += strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)+2)
+				}
+				line += cell
+			}
+		} else {
+			line = strings.Join(row, "\t")
+		}
+		if line = strings.TrimRight(line, " \t"); line != "" {
+			w.lineBreak(1)
+			w.preformatted(line)
+		}
+	}
+}
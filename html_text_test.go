@@ -0,0 +1,45 @@
+package httpdoc
+
+import "testing"
+
+func TestHtmlToText(t *testing.T) {
+	for src, expected := range map[string]string{
+		`Hello, <b>world</b>!`:                                        `Hello, world!`,
+		`<div>one</div><div>two</div>`:                                "one\ntwo",
+		`<h1>Title</h1><p>First  paragraph.</p><p>Second<br>line</p>`: "Title\n\nFirst paragraph.\n\nSecond\nline",
+		`<p>a</p><script type="text/javascript">var x = "<p>";</script><style media="all">p{}</style><noscript><p>js</p></noscript><template><p>tpl</p></template><p>b</p>`: "a\n\nb",
+		`<ul><li>one</li><li>two<ul><li>nested</li></ul></li></ul><ol start="2"><li>x</li><li>y</li></ol>`:                                                                  "- one\n- two\n  - nested\n\n2. x\n3. y",
+		"<pre>func main() {\n\tprintln(1)\n}\n</pre><p>after</p>":                                                                                                           "func main() {\n\tprintln(1)\n}\n\nafter",
+		`<table><tr><th>Name</th><th>Age</th></tr><tr><td>Bob</td><td>42</td></tr></table>`:                                                                                 "Name\tAge\nBob\t42",
+		`<img src="a.png" alt="Logo"> text &amp; more`:                                                                                                                      "Logo text & more",
+		`<blockquote>quoted <i>text</i></blockquote>`:                                                                                                                       "quoted text",
+		"\n  <p> padded </p>  ": "padded",
+	} {
+		assert(t, HtmlToText(src) == expected)
+	}
+}
+
+func TestHtmlToTextWithOptions(t *testing.T) {
+	text := HtmlToTextWithOptions(`<p>See <a href="https://go.dev/">Go site</a> and <a href="#top">top</a>.</p>
+		<table><tr><td>Name</td><td>Age</td></tr><tr><td>Alexander</td><td>7</td></tr></table>
+		<p>The quick brown fox jumps over the lazy dog</p>`, TextOptions{
+		Width:         20,
+		LinkFootnotes: true,
+		AlignTables:   true,
+	})
+	assert(t, text == "See Go site[1] and\ntop.\n\n"+
+		"Name       Age\n"+
+		"Alexander  7\n\n"+
+		"The quick brown fox\njumps over the lazy\ndog\n\n"+
+		"[1] https://go.dev/")
+}
+
+func TestHTMLElement_InnerText(t *testing.T) {
+	doc := NewDocument("https://example.com/").SetResponse(newHTMLResponse(), []byte(
+		`<table><tr><td>Alpha</td><td>Beta</td></tr><tr><td>Gamma</td><td>Delta</td></tr></table>`))
+
+	assert(t, doc.Find("table").First().InnerText() == "Alpha\tBeta\nGamma\tDelta")
+	assert(t, doc.Find("tr").First().InnerText() == "Alpha\tBeta")
+	assert(t, doc.GetElementsByTagName("tr").First().InnerText() == "Alpha\tBeta")
+	assert(t, doc.Find("td").First().InnerText() == "Alpha")
+}
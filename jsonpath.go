@@ -0,0 +1,818 @@
+package httpdoc
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/goldic/js"
+)
+
+// JSONPath returns values of json-document content selected by JSONPath-expression (with filters [?(@.price > 10)])
+func (d *Document) JSONPath(expr string) ([]any, error) {
+	if err := d.Load(); err != nil {
+		return nil, err
+	}
+	return JSONPath(d.Body, expr)
+}
+
+// JQ returns values of json-document content selected by subset of jq-expressions (paths, pipes, select).
+// Missing fields are skipped instead of returning null.
+func (d *Document) JQ(expr string) ([]any, error) {
+	if err := d.Load(); err != nil {
+		return nil, err
+	}
+	return JQ(d.Body, expr)
+}
+
+// JSONPath evaluates JSONPath-expression on data.
+// Data is json-encoded []byte or string (e.g. found by Document.Submatch), or decoded value.
+func JSONPath(data any, expr string) ([]any, error) {
+	p := &jsonPathParser{s: strings.TrimSpace(expr)}
+	if !p.consume("$") {
+		return nil, p.errorf("expression must start with $")
+	}
+	steps, err := p.parsePath()
+	if err == nil && !p.eof() {
+		err = p.errorf("unexpected symbol")
+	}
+	if err != nil {
+		return nil, err
+	}
+	root, err := decodeJSONData(data)
+	if err != nil {
+		return nil, err
+	}
+	return evalJSONSteps(steps, []any{root}, root), nil
+}
+
+// JQ evaluates jq-expression (subset of jq language) on data.
+// Data is json-encoded []byte or string, or decoded value.
+func JQ(data any, expr string) ([]any, error) {
+	p := &jsonPathParser{s: strings.TrimSpace(expr), jq: true}
+	steps, err := p.parsePipeline()
+	if err == nil && !p.eof() {
+		err = p.errorf("unexpected symbol")
+	}
+	if err != nil {
+		return nil, err
+	}
+	root, err := decodeJSONData(data)
+	if err != nil {
+		return nil, err
+	}
+	return evalJSONSteps(steps, []any{root}, root), nil
+}
+
+func decodeJSONData(data any) (v any, err error) {
+	switch d := data.(type) {
+	case []byte:
+		err = json.Unmarshal(d, &v)
+	case string:
+		err = json.Unmarshal([]byte(d), &v)
+	case json.RawMessage:
+		err = json.Unmarshal(d, &v)
+	default:
+		v = data
+	}
+	return
+}
+
+//------------ steps ------------
+
+type jsonStepKind int
+
+const (
+	stepField     jsonStepKind = iota // object member(s) by names
+	stepIndex                         // array element(s) by indexes
+	stepSlice                         // array slice
+	stepWildcard                      // all children
+	stepRecursive                     // node and all its descendants
+	stepFilter                        // children matching filter
+	stepSelect                        // node itself if it matches filter (jq select)
+)
+
+type jsonStep struct {
+	kind    jsonStepKind
+	names   []string
+	indexes []int
+	slice   [3]*int
+	filter  jsonExpr
+}
+
+func evalJSONSteps(steps []jsonStep, nodes []any, root any) []any {
+	for _, step := range steps {
+		var res []any
+		for _, node := range nodes {
+			res = step.apply(node, root, res)
+		}
+		nodes = res
+	}
+	if nodes == nil {
+		nodes = []any{}
+	}
+	return nodes
+}
+
+func (step jsonStep) apply(node, root any, res []any) []any {
+	switch step.kind {
+	case stepField:
+		if obj, ok := asJSONObject(node); ok {
+			for _, name := range step.names {
+				if v, ok := obj[name]; ok {
+					res = append(res, v)
+				}
+			}
+		}
+
+	case stepIndex:
+		if arr, ok := asJSONArray(node); ok {
+			for _, i := range step.indexes {
+				if i < 0 {
+					i += len(arr)
+				}
+				if i >= 0 && i < len(arr) {
+					res = append(res, arr[i])
+				}
+			}
+		}
+
+	case stepSlice:
+		if arr, ok := asJSONArray(node); ok {
+			n, start, end, inc := len(arr), 0, len(arr), 1
+			if step.slice[2] != nil {
+				inc = *step.slice[2]
+			}
+			if inc == 0 {
+				break
+			}
+			if inc < 0 {
+				start, end = n-1, -n-1
+			}
+			norm := func(i int) int {
+				if i < 0 {
+					return i + n
+				}
+				return i
+			}
+			if step.slice[0] != nil {
+				start = norm(*step.slice[0])
+			}
+			if step.slice[1] != nil {
+				end = norm(*step.slice[1])
+			} else if inc < 0 {
+				end = -1
+			}
+			for i := start; inc > 0 && i < end || inc < 0 && i > end; i += inc {
+				if i >= 0 && i < n {
+					res = append(res, arr[i])
+				}
+			}
+		}
+
+	case stepWildcard:
+		res = appendJSONChildren(res, node)
+
+	case stepRecursive:
+		res = appendJSONDescendants(append(res, node), node)
+
+	case stepFilter:
+		for _, child := range appendJSONChildren(nil, node) {
+			if step.filter.match(child, root) {
+				res = append(res, child)
+			}
+		}
+
+	case stepSelect:
+		if step.filter.match(node, root) {
+			res = append(res, node)
+		}
+	}
+	return res
+}
+
+func asJSONObject(v any) (map[string]any, bool) {
+	switch obj := v.(type) {
+	case map[string]any:
+		return obj, true
+	case js.Object:
+		return obj, true
+	}
+	return nil, false
+}
+
+func asJSONArray(v any) ([]any, bool) {
+	switch arr := v.(type) {
+	case []any:
+		return arr, true
+	case js.Array:
+		return arr, true
+	}
+	return nil, false
+}
+
+func appendJSONChildren(res []any, node any) []any {
+	if arr, ok := asJSONArray(node); ok {
+		return append(res, arr...)
+	}
+	if obj, ok := asJSONObject(node); ok {
+		keys := make([]string, 0, len(obj))
+		for key := range obj {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			res = append(res, obj[key])
+		}
+	}
+	return res
+}
+
+func appendJSONDescendants(res []any, node any) []any {
+	for _, child := range appendJSONChildren(nil, node) {
+		res = appendJSONDescendants(append(res, child), child)
+	}
+	return res
+}
+
+//------------ filter expressions ------------
+
+type jsonExpr interface {
+	match(node, root any) bool
+}
+
+type jsonOperand struct {
+	path     []jsonStep // relative path (isPath)
+	isPath   bool
+	fromRoot bool
+	value    any
+	re       *regexp.Regexp
+}
+
+func (o *jsonOperand) eval(node, root any) (any, bool) {
+	if !o.isPath {
+		return o.value, true
+	}
+	start := node
+	if o.fromRoot {
+		start = root
+	}
+	if res := evalJSONSteps(o.path, []any{start}, root); len(res) > 0 {
+		return res[0], true
+	}
+	return nil, false
+}
+
+type jsonExistsExpr struct {
+	operand *jsonOperand
+	truthy  bool // jq semantics: value is not null or false
+}
+
+func (e *jsonExistsExpr) match(node, root any) bool {
+	v, ok := e.operand.eval(node, root)
+	if e.truthy {
+		return ok && v != nil && v != false
+	}
+	return ok
+}
+
+type jsonCompareExpr struct {
+	op          string
+	left, right *jsonOperand
+}
+
+func (e *jsonCompareExpr) match(node, root any) bool {
+	a, okA := e.left.eval(node, root)
+	if e.op == "=~" {
+		s, ok := a.(string)
+		return okA && ok && e.right.re != nil && e.right.re.MatchString(s)
+	}
+	b, okB := e.right.eval(node, root)
+	if !okA || !okB {
+		return e.op == "!=" && okA != okB
+	}
+	switch e.op {
+	case "==":
+		return jsonEqual(a, b)
+	case "!=":
+		return !jsonEqual(a, b)
+	}
+	c, ok := jsonCompare(a, b)
+	if !ok {
+		return false
+	}
+	switch e.op {
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	}
+	return false
+}
+
+func jsonNumber(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func jsonEqual(a, b any) bool {
+	if x, ok := jsonNumber(a); ok {
+		y, ok := jsonNumber(b)
+		return ok && x == y
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func jsonCompare(a, b any) (int, bool) {
+	if x, ok := jsonNumber(a); ok {
+		if y, ok := jsonNumber(b); ok {
+			switch {
+			case x < y:
+				return -1, true
+			case x > y:
+				return 1, true
+			}
+			return 0, true
+		}
+	}
+	if x, ok := a.(string); ok {
+		if y, ok := b.(string); ok {
+			return strings.Compare(x, y), true
+		}
+	}
+	return 0, false
+}
+
+type jsonLogicExpr struct {
+	op          string // "&&", "||", "!"
+	left, right jsonExpr
+}
+
+func (e *jsonLogicExpr) match(node, root any) bool {
+	switch e.op {
+	case "&&":
+		return e.left.match(node, root) && e.right.match(node, root)
+	case "||":
+		return e.left.match(node, root) || e.right.match(node, root)
+	default:
+		return !e.left.match(node, root)
+	}
+}
+
+//------------ parser ------------
+
+type jsonPathParser struct {
+	s   string
+	pos int
+	jq  bool
+}
+
+func (p *jsonPathParser) errorf(msg string, args ...any) error {
+	return fmt.Errorf("httpdoc: invalid json-path %q at position %d: %s", p.s, p.pos, fmt.Sprintf(msg, args...))
+}
+
+func (p *jsonPathParser) eof() bool {
+	p.skipSpaces()
+	return p.pos >= len(p.s)
+}
+
+func (p *jsonPathParser) skipSpaces() {
+	for p.pos < len(p.s) && unicode.IsSpace(rune(p.s[p.pos])) {
+		p.pos++
+	}
+}
+
+func (p *jsonPathParser) peek(prefix string) bool {
+	return strings.HasPrefix(p.s[p.pos:], prefix)
+}
+
+func (p *jsonPathParser) consume(prefix string) bool {
+	if p.peek(prefix) {
+		p.pos += len(prefix)
+		return true
+	}
+	return false
+}
+
+func (p *jsonPathParser) consumeKeyword(word string) bool {
+	if p.peek(word) {
+		end := p.pos + len(word)
+		if end == len(p.s) || !isJSONNameChar(rune(p.s[end])) {
+			p.pos = end
+			return true
+		}
+	}
+	return false
+}
+
+func isJSONNameChar(r rune) bool {
+	return r == '_' || r == '$' || r == '-' || unicode.IsLetter(r) || unicode.IsDigit(r) || r > 127
+}
+
+func (p *jsonPathParser) parseName() string {
+	start := p.pos
+	for p.pos < len(p.s) && isJSONNameChar(rune(p.s[p.pos])) {
+		if p.jq && p.s[p.pos] == '-' {
+			break
+		}
+		p.pos++
+	}
+	return p.s[start:p.pos]
+}
+
+// parsePath parses sequence of JSONPath selectors (after "$" or "@")
+func (p *jsonPathParser) parsePath() (steps []jsonStep, err error) {
+	for {
+		switch {
+		case p.consume(".."):
+			steps = append(steps, jsonStep{kind: stepRecursive})
+			if p.consume("*") {
+				steps = append(steps, jsonStep{kind: stepWildcard})
+			} else if p.peek("[") {
+				continue
+			} else if name := p.parseName(); name != "" {
+				steps = append(steps, jsonStep{kind: stepField, names: []string{name}})
+			} else {
+				return nil, p.errorf("name expected after ..")
+			}
+
+		case p.consume("."):
+			if p.consume("*") {
+				steps = append(steps, jsonStep{kind: stepWildcard})
+			} else if name := p.parseName(); name != "" {
+				steps = append(steps, jsonStep{kind: stepField, names: []string{name}})
+			} else {
+				return nil, p.errorf("name expected")
+			}
+
+		case p.consume("["):
+			step, err := p.parseBracket()
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, step)
+
+		default:
+			return steps, nil
+		}
+	}
+}
+
+// parseBracket parses selector in brackets (after "[")
+func (p *jsonPathParser) parseBracket() (step jsonStep, err error) {
+	p.skipSpaces()
+	switch {
+	case p.consume("*"):
+		step.kind = stepWildcard
+
+	case p.jq && p.peek("]"):
+		step.kind = stepWildcard
+
+	case p.consume("?"):
+		p.skipSpaces()
+		step.kind = stepFilter
+		if step.filter, err = p.parseExpr(); err != nil {
+			return
+		}
+
+	case p.peek("'") || p.peek(`"`):
+		step.kind = stepField
+		for {
+			p.skipSpaces()
+			name, err := p.parseString()
+			if err != nil {
+				return step, err
+			}
+			step.names = append(step.names, name)
+			if p.skipSpaces(); !p.consume(",") {
+				break
+			}
+		}
+
+	default:
+		var nums [3]*int
+		var n, colons int
+		for {
+			p.skipSpaces()
+			if num, ok := p.parseInt(); ok {
+				nums[colons] = &num
+				n++
+			}
+			p.skipSpaces()
+			if p.consume(":") {
+				if colons++; colons > 2 {
+					return step, p.errorf("invalid slice")
+				}
+				continue
+			}
+			if colons == 0 && p.consume(",") {
+				if nums[0] == nil {
+					return step, p.errorf("index expected")
+				}
+				step.indexes = append(step.indexes, *nums[0])
+				nums[0] = nil
+				continue
+			}
+			break
+		}
+		if colons > 0 {
+			step.kind, step.slice = stepSlice, nums
+		} else if nums[0] != nil {
+			step.kind, step.indexes = stepIndex, append(step.indexes, *nums[0])
+		} else {
+			return step, p.errorf("index expected")
+		}
+	}
+	p.skipSpaces()
+	if !p.consume("]") {
+		return step, p.errorf("] expected")
+	}
+	return
+}
+
+func (p *jsonPathParser) parseInt() (int, bool) {
+	start := p.pos
+	if p.pos < len(p.s) && p.s[p.pos] == '-' {
+		p.pos++
+	}
+	for p.pos < len(p.s) && p.s[p.pos] >= '0' && p.s[p.pos] <= '9' {
+		p.pos++
+	}
+	n, err := strconv.Atoi(p.s[start:p.pos])
+	if err != nil {
+		p.pos = start
+		return 0, false
+	}
+	return n, true
+}
+
+func (p *jsonPathParser) parseString() (string, error) {
+	if p.pos >= len(p.s) || p.s[p.pos] != '\'' && p.s[p.pos] != '"' {
+		return "", p.errorf("string expected")
+	}
+	quote := p.s[p.pos]
+	var sb strings.Builder
+	for p.pos++; p.pos < len(p.s); p.pos++ {
+		c := p.s[p.pos]
+		if c == quote {
+			p.pos++
+			return sb.String(), nil
+		}
+		if c == '\\' && p.pos+1 < len(p.s) {
+			p.pos++
+			switch c = p.s[p.pos]; c {
+			case 'n':
+				c = '\n'
+			case 't':
+				c = '\t'
+			}
+		}
+		sb.WriteByte(c)
+	}
+	return "", p.errorf("unterminated string")
+}
+
+// parsePipeline parses jq-expression: term | term | ...
+func (p *jsonPathParser) parsePipeline() (steps []jsonStep, err error) {
+	for {
+		p.skipSpaces()
+		var ss []jsonStep
+		if p.consumeKeyword("select") {
+			if p.skipSpaces(); !p.consume("(") {
+				return nil, p.errorf("( expected")
+			}
+			filter, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			if p.skipSpaces(); !p.consume(")") {
+				return nil, p.errorf(") expected")
+			}
+			ss = []jsonStep{{kind: stepSelect, filter: filter}}
+		} else if ss, err = p.parseJQPath(); err != nil {
+			return nil, err
+		}
+		steps = append(steps, ss...)
+		if p.skipSpaces(); !p.consume("|") {
+			return steps, nil
+		}
+	}
+}
+
+// parseJQPath parses jq path like .a.b[0][]."name"
+func (p *jsonPathParser) parseJQPath() (steps []jsonStep, err error) {
+	if !p.peek(".") {
+		return nil, p.errorf(". expected")
+	}
+	for {
+		switch {
+		case p.consume(".."):
+			steps = append(steps, jsonStep{kind: stepRecursive})
+
+		case p.consume("."):
+			if p.peek(`"`) {
+				name, err := p.parseString()
+				if err != nil {
+					return nil, err
+				}
+				steps = append(steps, jsonStep{kind: stepField, names: []string{name}})
+			} else if name := p.parseName(); name != "" {
+				steps = append(steps, jsonStep{kind: stepField, names: []string{name}})
+			}
+
+		case p.consume("["):
+			step, err := p.parseBracket()
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, step)
+
+		case p.consume("?"): // optional: errors are ignored anyway
+
+		default:
+			return steps, nil
+		}
+	}
+}
+
+func (p *jsonPathParser) parseExpr() (jsonExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipSpaces()
+		if !p.consume("||") && !(p.jq && p.consumeKeyword("or")) {
+			return left, nil
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &jsonLogicExpr{op: "||", left: left, right: right}
+	}
+}
+
+func (p *jsonPathParser) parseAnd() (jsonExpr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipSpaces()
+		if !p.consume("&&") && !(p.jq && p.consumeKeyword("and")) {
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &jsonLogicExpr{op: "&&", left: left, right: right}
+	}
+}
+
+func (p *jsonPathParser) parseUnary() (jsonExpr, error) {
+	p.skipSpaces()
+	if p.peek("!") && !p.peek("!=") {
+		p.pos++
+		e, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &jsonLogicExpr{op: "!", left: e}, nil
+	}
+	if p.consume("(") {
+		e, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if p.skipSpaces(); !p.consume(")") {
+			return nil, p.errorf(") expected")
+		}
+		return p.parseJQNot(e), nil
+	}
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	for _, op := range []string{"==", "!=", "<=", ">=", "=~", "<", ">"} {
+		if p.consume(op) {
+			p.skipSpaces()
+			right, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+			if op == "=~" && right.re == nil {
+				s, ok := right.value.(string)
+				if !ok {
+					return nil, p.errorf("regular expression expected")
+				}
+				if right.re, err = regexp.Compile(s); err != nil {
+					return nil, p.errorf("%v", err)
+				}
+			}
+			return p.parseJQNot(&jsonCompareExpr{op: op, left: left, right: right}), nil
+		}
+	}
+	return p.parseJQNot(&jsonExistsExpr{operand: left, truthy: p.jq}), nil
+}
+
+// parseJQNot handles jq postfix negation "expr | not"
+func (p *jsonPathParser) parseJQNot(e jsonExpr) jsonExpr {
+	if !p.jq {
+		return e
+	}
+	save := p.pos
+	if p.skipSpaces(); p.consume("|") {
+		if p.skipSpaces(); p.consumeKeyword("not") {
+			return &jsonLogicExpr{op: "!", left: e}
+		}
+	}
+	p.pos = save
+	return e
+}
+
+func (p *jsonPathParser) parseOperand() (o *jsonOperand, err error) {
+	p.skipSpaces()
+	o = &jsonOperand{}
+	switch {
+	case !p.jq && (p.peek("@") || p.peek("$")):
+		o.isPath, o.fromRoot = true, p.s[p.pos] == '$'
+		p.pos++
+		o.path, err = p.parsePath()
+
+	case p.jq && p.peek("."):
+		o.isPath = true
+		o.path, err = p.parseJQPath()
+
+	case p.jq && p.peek("$"):
+		return nil, p.errorf("variables are not supported")
+
+	case p.peek("'") || p.peek(`"`):
+		o.value, err = p.parseString()
+
+	case p.peek("/"): // regular expression /.../flags
+		end := strings.IndexByte(p.s[p.pos+1:], '/')
+		for end >= 0 && p.s[p.pos+end] == '\\' {
+			next := strings.IndexByte(p.s[p.pos+end+2:], '/')
+			if next < 0 {
+				end = -1
+				break
+			}
+			end += next + 1
+		}
+		if end < 0 {
+			return nil, p.errorf("unterminated regular expression")
+		}
+		src := p.s[p.pos+1 : p.pos+1+end]
+		p.pos += end + 2
+		if flags := p.parseName(); flags != "" {
+			src = "(?" + flags + ")" + src
+		}
+		if o.re, err = regexp.Compile(src); err != nil {
+			return nil, p.errorf("%v", err)
+		}
+
+	case p.consumeKeyword("true"):
+		o.value = true
+	case p.consumeKeyword("false"):
+		o.value = false
+	case p.consumeKeyword("null"):
+		o.value = nil
+
+	default:
+		start := p.pos
+		for p.pos < len(p.s) && strings.IndexByte("+-.0123456789eE", p.s[p.pos]) >= 0 {
+			p.pos++
+		}
+		num, err := strconv.ParseFloat(p.s[start:p.pos], 64)
+		if err != nil {
+			p.pos = start
+			return nil, p.errorf("operand expected")
+		}
+		o.value = num
+	}
+	return
+}
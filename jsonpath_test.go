@@ -0,0 +1,109 @@
+package httpdoc
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+const testStoreJSON = `{
+	"store": {
+		"book": [
+			{"category": "reference", "author": "Nigel Rees", "title": "Sayings of the Century", "price": 8.95},
+			{"category": "fiction", "author": "Evelyn Waugh", "title": "Sword of Honour", "price": 12.99},
+			{"category": "fiction", "author": "Herman Melville", "title": "Moby Dick", "isbn": "0-553-21311-3", "price": 8.99},
+			{"category": "fiction", "author": "J. R. R. Tolkien", "title": "The Lord of the Rings", "isbn": "0-395-19395-8", "price": 22.99}
+		],
+		"bicycle": {"color": "red", "price": 19.95}
+	}
+}`
+
+func jsonStr(v any) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func TestJSONPath(t *testing.T) {
+	for expr, expected := range map[string]string{
+		`$.store.book[0].title`:                             `["Sayings of the Century"]`,
+		`$['store']['bicycle']['color']`:                    `["red"]`,
+		`$.store.book[-1].author`:                           `["J. R. R. Tolkien"]`,
+		`$.store.book[0,2].price`:                           `[8.95,8.99]`,
+		`$.store.book[1:3].price`:                           `[12.99,8.99]`,
+		`$.store.book[::-2].price`:                          `[22.99,12.99]`,
+		`$.store.book[*].isbn`:                              `["0-553-21311-3","0-395-19395-8"]`,
+		`$..price`:                                          `[19.95,8.95,12.99,8.99,22.99]`,
+		`$.store.book[?(@.price > 10)].title`:               `["Sword of Honour","The Lord of the Rings"]`,
+		`$..book[?(@.isbn && @.price < 10)].isbn`:           `["0-553-21311-3"]`,
+		`$..book[?(!@.isbn)].price`:                         `[8.95,12.99]`,
+		`$..book[?(@.author =~ /^h/i)].title`:               `["Moby Dick"]`,
+		`$..book[?(@.category == 'reference')].price`:       `[8.95]`,
+		`$..book[?(@.price > $.store.bicycle.price)].title`: `["The Lord of the Rings"]`,
+		"$..book[?(@.price > 10\n\t&& @.isbn)].title":       `["The Lord of the Rings"]`,
+		`$.store.none`:                                      `[]`,
+	} {
+		res, err := JSONPath(testStoreJSON, expr)
+		assert(t, err == nil)
+		assert(t, jsonStr(res) == expected)
+	}
+
+	for _, expr := range []string{`store.book`, `$.store[`, `$..book[?(@.price >)]`, `$.a[1:2:3:4]`, `$[,1]`, `$[1,]`} {
+		_, err := JSONPath(testStoreJSON, expr)
+		assert(t, err != nil)
+	}
+}
+
+func TestJQ(t *testing.T) {
+	for expr, expected := range map[string]string{
+		`.`:                         `[` + jsonStr(mustDecodeJSON(testStoreJSON)) + `]`,
+		`.store.bicycle.color`:      `["red"]`,
+		`.store."bicycle"["price"]`: `[19.95]`,
+		`.store.book[2].author`:     `["Herman Melville"]`,
+		`.store.book[] | .price`:    `[8.95,12.99,8.99,22.99]`,
+		`.store.book[] | select(.price > 10 and .isbn) | .title`:   `["The Lord of the Rings"]`,
+		`.store.book[] | select(.isbn | not) | .price`:             `[8.95,12.99]`,
+		`.store.book[] | select(.category != "fiction") | .author`: `["Nigel Rees"]`,
+		`.. | .color?`: `["red"]`,
+	} {
+		res, err := JQ(testStoreJSON, expr)
+		assert(t, err == nil)
+		assert(t, jsonStr(res) == expected)
+	}
+
+	for _, expr := range []string{`.[,1]`, `.[1,]`} {
+		_, err := JQ(`[1,2,3]`, expr)
+		assert(t, err != nil)
+	}
+}
+
+func mustDecodeJSON(s string) (v any) {
+	panicOnErr(json.Unmarshal([]byte(s), &v))
+	return
+}
+
+func TestDocument_JSONPath(t *testing.T) {
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(testStoreJSON))
+		default:
+			w.Write([]byte(`<html><script>var data = {"items":[{"id":1},{"id":2}]};</script></html>`))
+		}
+	})
+
+	doc := newDocument(srv.URL+"/api", NewClient(), nil)
+	res, err := doc.JSONPath(`$.store.book[?(@.price < 9)].title`)
+	assert(t, err == nil)
+	assert(t, jsonStr(res) == `["Sayings of the Century","Moby Dick"]`)
+
+	res, err = doc.JQ(`.store.book | .[1].author`)
+	assert(t, err == nil)
+	assert(t, jsonStr(res) == `["Evelyn Waugh"]`)
+
+	// embedded json
+	doc = newDocument(srv.URL+"/page", NewClient(), nil)
+	res, err = JSONPath(doc.Submatch(`var data = (.+?);`, 1), `$.items[*].id`)
+	assert(t, err == nil)
+	assert(t, jsonStr(res) == `[1,2]`)
+}
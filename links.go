@@ -0,0 +1,232 @@
+package httpdoc
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// LinkKind is kind of link of document
+type LinkKind string
+
+const (
+	LinkNavigation LinkKind = "navigation" // links to pages: <a>, <area>, <iframe>, <link rel="next">, meta refresh
+	LinkAsset      LinkKind = "asset"      // resources of page: images, scripts, styles, media, icons
+	LinkCanonical  LinkKind = "canonical"  // <link rel="canonical">
+	LinkFeed       LinkKind = "feed"       // RSS, Atom and JSON feeds
+)
+
+// Link is absolute url found in html-document
+type Link struct {
+	URL      string   // absolute normalized url (see NormalizeURL; trailing slash is kept)
+	Kind     LinkKind // kind of link
+	Tag      string   // name of tag where url is found ("a", "img", "style", ...)
+	Rel      []string // lower-case values of rel attribute (e.g. "nofollow", "sponsored", "ugc")
+	Text     string   // text of anchor (or alt-text of image, or title)
+	External bool     // host of url differs from host of document ("www." prefix is ignored)
+}
+
+var reCSSURL = regexp.MustCompile(`(?i)url\(\s*(?:"([^"]*)"|'([^']*)'|([^)'"\s]*))\s*\)|@import\s+(?:"([^"]*)"|'([^']*)')`)
+
+// feedTypes are mime-types of feeds in <link rel="alternate" type="...">
+var feedTypes = map[string]bool{
+	"application/rss+xml": true, "application/atom+xml": true, "application/feed+json": true, "application/json+feed": true,
+	"application/rdf+xml": true,
+}
+
+// assetRels are values of rel attribute of <link> for resources of page
+var assetRels = map[string]bool{
+	"stylesheet": true, "icon": true, "shortcut": true, "apple-touch-icon": true, "apple-touch-icon-precomposed": true,
+	"mask-icon": true, "manifest": true, "preload": true, "prefetch": true, "modulepreload": true, "image_src": true,
+}
+
+// HasRel reports whether link has value of rel attribute (e.g. "nofollow")
+func (l *Link) HasRel(rel string) bool {
+	for _, r := range l.Rel {
+		if r == rel {
+			return true
+		}
+	}
+	return false
+}
+
+// AllLinks returns unique absolute http(s)-urls of links and resources of html-document (<a>, <img>, <script>, css url(), etc)
+func (d *Document) AllLinks() []*Link {
+	base := d.BaseURL()
+	host := strings.TrimPrefix(strings.ToLower(d.URL().Hostname()), "www.")
+	var links []*Link
+	found := map[string]bool{}
+	add := func(n *html.Node, kind LinkKind, rawURL string, rel []string, text string) {
+		u, err := url.Parse(strings.TrimSpace(rawURL))
+		if err != nil || rawURL == "" {
+			return
+		}
+		u = base.ResolveReference(u)
+		if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+			return
+		}
+		href, err := NormalizeURL(u.String(), NormalizeOptions{KeepTrailingSlash: true})
+		if err != nil {
+			return
+		}
+		l := &Link{
+			URL:      href,
+			Kind:     kind,
+			Tag:      n.Data,
+			Rel:      rel,
+			Text:     text,
+			External: strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.") != host,
+		}
+		if key := string(kind) + " " + l.URL; !found[key] {
+			found[key] = true
+			links = append(links, l)
+		}
+	}
+	walkHTML(d.htmlRoot(), func(n *html.Node) {
+		rel := strings.Fields(strings.ToLower(htmlAttr(n, "rel")))
+		switch n.Data {
+		case "a", "area":
+			add(n, LinkNavigation, htmlAttr(n, "href"), rel, linkText(n))
+
+		case "link":
+			if kind := linkKind(n, rel); kind != "" {
+				add(n, kind, htmlAttr(n, "href"), rel, strings.TrimSpace(htmlAttr(n, "title")))
+			}
+
+		case "img", "source":
+			alt := strings.TrimSpace(htmlAttr(n, "alt"))
+			add(n, LinkAsset, htmlAttr(n, "src"), nil, alt)
+			for _, src := range parseSrcset(htmlAttr(n, "srcset")) {
+				add(n, LinkAsset, src, nil, alt)
+			}
+
+		case "script", "embed", "track":
+			add(n, LinkAsset, htmlAttr(n, "src"), nil, "")
+
+		case "video", "audio":
+			add(n, LinkAsset, htmlAttr(n, "src"), nil, "")
+			add(n, LinkAsset, htmlAttr(n, "poster"), nil, "")
+
+		case "iframe", "frame":
+			add(n, LinkNavigation, htmlAttr(n, "src"), nil, strings.TrimSpace(htmlAttr(n, "title")))
+
+		case "style":
+			for _, src := range cssURLs(htmlNodeRawText(n)) {
+				add(n, LinkAsset, src, nil, "")
+			}
+
+		case "meta":
+			if strings.EqualFold(htmlAttr(n, "http-equiv"), "refresh") {
+				if _, href, ok := parseMetaRefresh(htmlAttr(n, "content")); ok {
+					add(n, LinkNavigation, href, nil, "")
+				}
+			}
+		}
+		if style := htmlAttr(n, "style"); style != "" {
+			for _, src := range cssURLs(style) {
+				add(n, LinkAsset, src, nil, "")
+			}
+		}
+	})
+	return links
+}
+
+// linkKind returns kind of <link> (or "" for hints of connections "preconnect", "dns-prefetch")
+func linkKind(n *html.Node, rel []string) LinkKind {
+	for _, r := range rel {
+		switch {
+		case r == "preconnect" || r == "dns-prefetch":
+			return ""
+		case r == "canonical":
+			return LinkCanonical
+		case r == "alternate" && feedTypes[strings.ToLower(strings.TrimSpace(htmlAttr(n, "type")))]:
+			return LinkFeed
+		case assetRels[r]:
+			return LinkAsset
+		}
+	}
+	return LinkNavigation
+}
+
+// linkText returns text of anchor, alt-text of its image or its title
+func linkText(n *html.Node) string {
+	if text := htmlCellText(n); text != "" {
+		return text
+	}
+	if img := findHTMLNode(n, "img"); img != nil {
+		if alt := strings.TrimSpace(htmlAttr(img, "alt")); alt != "" {
+			return alt
+		}
+	}
+	return strings.TrimSpace(htmlAttr(n, "title"))
+}
+
+// parseSrcset returns urls of image candidates of srcset attribute ("a.jpg 1x, b.jpg 2x")
+func parseSrcset(s string) (urls []string) {
+	for {
+		if s = strings.TrimLeft(s, " \t\n\r\f,"); s == "" {
+			return
+		}
+		i := strings.IndexAny(s, " \t\n\r\f")
+		if i < 0 {
+			i = len(s)
+		}
+		u := s[:i]
+		if s = s[i:]; strings.HasSuffix(u, ",") { // candidate without descriptor
+			u = strings.TrimRight(u, ",")
+		} else if j := strings.IndexByte(s, ','); j >= 0 { // skip descriptor
+			s = s[j+1:]
+		} else {
+			s = ""
+		}
+		urls = append(urls, u)
+	}
+}
+
+// cssURLs returns urls of url() and @import of css
+func cssURLs(css string) (urls []string) {
+	for _, ss := range reCSSURL.FindAllStringSubmatch(css, -1) {
+		for _, s := range ss[1:] {
+			if s = strings.TrimSpace(s); s != "" && !strings.HasPrefix(strings.ToLower(s), "data:") {
+				urls = append(urls, s)
+				break
+			}
+		}
+	}
+	return
+}
+
+// parseMetaRefresh parses content of <meta http-equiv="refresh" content="5; url=/next">.
+// It returns ok=false if content has no url (page is reloaded).
+func parseMetaRefresh(content string) (delay float64, href string, ok bool) {
+	s := strings.TrimSpace(content)
+	i := 0
+	for i < len(s) && (s[i] >= '0' && s[i] <= '9' || s[i] == '.') {
+		i++
+	}
+	if i == 0 {
+		return 0, "", false
+	}
+	delay, _ = strconv.ParseFloat(s[:i], 64)
+	if s = strings.TrimSpace(s[i:]); s == "" || s[0] != ';' && s[0] != ',' {
+		return delay, "", false
+	}
+	s = strings.TrimSpace(s[1:])
+	if len(s) > 3 && strings.EqualFold(s[:3], "url") {
+		if rest := strings.TrimSpace(s[3:]); strings.HasPrefix(rest, "=") {
+			s = strings.TrimSpace(rest[1:])
+		}
+	}
+	if s != "" && (s[0] == '"' || s[0] == '\'') {
+		if j := strings.IndexByte(s[1:], s[0]); j >= 0 {
+			s = s[1 : j+1]
+		} else {
+			s = s[1:]
+		}
+	}
+	href = strings.TrimSpace(s)
+	return delay, href, href != ""
+}
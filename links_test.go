@@ -0,0 +1,70 @@
+package httpdoc
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDocument_AllLinks(t *testing.T) {
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><head>
+			<meta http-equiv="refresh" content="30; URL='/reloaded'">
+			<link rel="canonical" href="/page#top">
+			<link rel="alternate" type="application/rss+xml" title="News" href="/feed.xml">
+			<link rel="stylesheet" href="/css/main.css">
+			<link rel="preconnect" href="https://cdn.example.com">
+			<style>body { background: url("/img/bg.png") } @import 'print.css'; .x { background: url(data:image/png;base64,AA) }</style>
+			<script src="/js/app.js"></script>
+		</head><body>
+			<a href="about">About <b>us</b></a>
+			<a href="https://www.partner.com/x" rel="nofollow sponsored">Partner</a>
+			<a href="http://www.` + r.Host + `/about#team"><img src="/img/team.jpg" srcset="/img/team-2x.jpg 2x, /img/team-3x.jpg 3x" alt="Team"></a>
+			<a href="javascript:void(0)">js</a> <a href="mailto:a@b.c">mail</a> <a href="#top">top</a>
+			<picture><source srcset="/img/a.webp 1x,/img/b.webp 2x"></picture>
+			<div style="background-image: url('/img/div.png')"></div>
+			<a href="HTTPS://WWW.PARTNER.COM:443/./x?utm_campaign=1#comments">Partner again</a>
+		</body></html>`))
+	})
+
+	doc := newDocument(srv.URL+"/dir/page", NewClient(), nil)
+	var res []string
+	for _, l := range doc.AllLinks() {
+		res = append(res, fmt.Sprintf("%s %s %s %q %v %v", l.Kind, l.Tag, strings.TrimPrefix(l.URL, srv.URL), l.Text, l.Rel, l.External))
+	}
+	assert(t, strings.Join(res, "\n") == strings.Join([]string{
+		`navigation meta /reloaded "" [] false`,
+		`canonical link /page "" [canonical] false`,
+		`feed link /feed.xml "News" [alternate] false`,
+		`asset link /css/main.css "" [stylesheet] false`,
+		`asset style /img/bg.png "" [] false`,
+		`asset style /dir/print.css "" [] false`,
+		`asset script /js/app.js "" [] false`,
+		`navigation a /dir/about "About us" [] false`,
+		`navigation a https://www.partner.com/x "Partner" [nofollow sponsored] true`,
+		`navigation a http://www.` + srv.Listener.Addr().String() + `/about "Team" [] false`,
+		`asset img /img/team.jpg "Team" [] false`,
+		`asset img /img/team-2x.jpg "Team" [] false`,
+		`asset img /img/team-3x.jpg "Team" [] false`,
+		`navigation a /dir/page "top" [] false`,
+		`asset source /img/a.webp "" [] false`,
+		`asset source /img/b.webp "" [] false`,
+		`asset div /img/div.png "" [] false`,
+	}, "\n"))
+	assert(t, doc.AllLinks()[8].HasRel("nofollow"))
+}
+
+func TestParseMetaRefresh(t *testing.T) {
+	for content, expected := range map[string]string{
+		`0;url=/next`:         `0 /next true`,
+		`5; URL="http://a/b"`: `5 http://a/b true`,
+		` 1.5 , 'x.html' `:    `1.5 x.html true`,
+		`10`:                  `10  false`,
+		`url=/x`:              `0  false`,
+		`3;url=/a b`:          `3 /a b true`,
+	} {
+		delay, href, ok := parseMetaRefresh(content)
+		assert(t, fmt.Sprint(delay, " ", href, " ", ok) == expected)
+	}
+}
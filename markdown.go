@@ -0,0 +1,344 @@
+package httpdoc
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	reMdSpaces       = regexp.MustCompile(`[ \t\r\n\f]+`)
+	reMdMultiSpaces  = regexp.MustCompile(` {2,}`)
+	reMdBlankLines   = regexp.MustCompile(`\n{3,}`)
+	reMdLineStart    = regexp.MustCompile(`^(#{1,6}\s|>|[-+]\s)`)
+	reMdOrderedStart = regexp.MustCompile(`^(\d+)([.)]\s)`)
+	reMdCodeLanguage = regexp.MustCompile(`(?:^|\s)(?:language|lang)-([\w+#-]+)`)
+	mdEscaper        = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `_`, `\_`, "`", "\\`", `[`, `\[`, `]`, `\]`, `<`, `\<`)
+)
+
+const mdHardBreak = "\x00" // placeholder of <br> in inline content
+
+// Markdown converts html-document body to markdown (CommonMark with GFM tables and strikethrough)
+func (d *Document) Markdown() string {
+	root := d.htmlRoot()
+	if body := findHTMLNode(root, "body"); body != nil {
+		root = body
+	}
+	return newMarkdownConverter(d.BaseURL()).convert(root)
+}
+
+// Markdown converts html-element to markdown (CommonMark with GFM tables and strikethrough)
+func (e *HTMLElement) Markdown() string {
+	if e == nil {
+		return ""
+	}
+	context := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(e.String()), context)
+	if err != nil {
+		return ""
+	}
+	for _, n := range nodes {
+		context.AppendChild(n)
+	}
+	return newMarkdownConverter(e.Document.BaseURL()).convert(context)
+}
+
+type markdownConverter struct {
+	base *url.URL
+}
+
+func newMarkdownConverter(base *url.URL) *markdownConverter {
+	return &markdownConverter{base: base}
+}
+
+func (c *markdownConverter) convert(n *html.Node) string {
+	return strings.TrimSpace(reMdBlankLines.ReplaceAllString(c.blocks(n, "\n\n"), "\n\n"))
+}
+
+func isMarkdownSkipped(n *html.Node) bool {
+	switch n.Data {
+	case "head", "script", "style", "noscript", "template", "iframe", "svg", "canvas", "button", "input", "select", "textarea", "option":
+		return true
+	}
+	return false
+}
+
+func isMarkdownBlock(n *html.Node) bool {
+	switch n.Data {
+	case "p", "div", "section", "article", "main", "header", "footer", "nav", "aside", "address", "form", "fieldset",
+		"h1", "h2", "h3", "h4", "h5", "h6", "ul", "ol", "li", "pre", "blockquote", "table", "hr",
+		"figure", "figcaption", "dl", "dt", "dd", "details", "summary":
+		return true
+	}
+	return false
+}
+
+// blocks converts children of node to markdown blocks joined by separator
+func (c *markdownConverter) blocks(n *html.Node, sep string) string {
+	var blocks []string
+	var inline strings.Builder
+	flush := func() {
+		if s := c.paragraph(inline.String()); s != "" {
+			blocks = append(blocks, s)
+		}
+		inline.Reset()
+	}
+	for ch := n.FirstChild; ch != nil; ch = ch.NextSibling {
+		if ch.Type == html.ElementNode && isMarkdownSkipped(ch) {
+			continue
+		}
+		if ch.Type == html.ElementNode && isMarkdownBlock(ch) {
+			flush()
+			if s := c.block(ch); s != "" {
+				blocks = append(blocks, s)
+			}
+			continue
+		}
+		inline.WriteString(c.inline(ch))
+	}
+	flush()
+	return strings.Join(blocks, sep)
+}
+
+// paragraph normalizes inline content and escapes markers of blocks at its start
+func (c *markdownConverter) paragraph(s string) string {
+	if s = normMarkdownInline(s, "  \n"); reMdLineStart.MatchString(s) {
+		s = `\` + s
+	}
+	return reMdOrderedStart.ReplaceAllString(s, `$1\$2`) // backslash before digit is not escape
+}
+
+// normMarkdownInline collapses spaces of inline content and replaces hard breaks
+func normMarkdownInline(s, hardBreak string) string {
+	s = reMdMultiSpaces.ReplaceAllString(s, " ")
+	s = strings.ReplaceAll(s, " "+mdHardBreak, mdHardBreak)
+	s = strings.ReplaceAll(s, mdHardBreak+" ", mdHardBreak)
+	s = strings.Trim(s, " "+mdHardBreak)
+	return strings.ReplaceAll(s, mdHardBreak, hardBreak)
+}
+
+func (c *markdownConverter) block(n *html.Node) string {
+	switch n.Data {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		level, _ := strconv.Atoi(n.Data[1:])
+		if text := normMarkdownInline(c.inlineChildren(n), " "); text != "" {
+			return strings.Repeat("#", level) + " " + text
+		}
+		return ""
+
+	case "ul", "ol":
+		return c.list(n)
+
+	case "pre":
+		return c.codeBlock(n)
+
+	case "blockquote":
+		s := c.blocks(n, "\n\n")
+		if s == "" {
+			return ""
+		}
+		lines := strings.Split(s, "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimRight("> "+line, " ")
+		}
+		return strings.Join(lines, "\n")
+
+	case "hr":
+		return "---"
+
+	case "table":
+		return c.table(n)
+
+	case "dt":
+		if s := normMarkdownInline(c.inlineChildren(n), " "); s != "" {
+			return "**" + s + "**"
+		}
+		return ""
+
+	default:
+		return c.blocks(n, "\n\n")
+	}
+}
+
+func (c *markdownConverter) list(n *html.Node) string {
+	num, ordered := 1, n.Data == "ol"
+	if start, err := strconv.Atoi(htmlAttr(n, "start")); err == nil {
+		num = start
+	}
+	var items []string
+	for li := n.FirstChild; li != nil; li = li.NextSibling {
+		if li.Type != html.ElementNode {
+			continue
+		}
+		var content string
+		if li.Data == "li" {
+			content = c.blocks(li, "\n")
+		} else {
+			content = c.block(li)
+		}
+		marker := "- "
+		if ordered {
+			marker = strconv.Itoa(num) + ". "
+			num++
+		}
+		lines := strings.Split(content, "\n")
+		for i := 1; i < len(lines); i++ {
+			if lines[i] != "" {
+				lines[i] = strings.Repeat(" ", len(marker)) + lines[i]
+			}
+		}
+		items = append(items, marker+strings.Join(lines, "\n"))
+	}
+	return strings.Join(items, "\n")
+}
+
+func (c *markdownConverter) codeBlock(n *html.Node) string {
+	code := strings.TrimSuffix(htmlNodeRawText(n), "\n")
+	lang := ""
+	for _, el := range []*html.Node{n, findHTMLNode(n, "code")} {
+		if el != nil {
+			if ss := reMdCodeLanguage.FindStringSubmatch(htmlAttr(el, "class")); ss != nil {
+				lang = ss[1]
+				break
+			}
+		}
+	}
+	fence := "```"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+	return fence + lang + "\n" + code + "\n" + fence
+}
+
+func (c *markdownConverter) table(n *html.Node) string {
+	var rows [][]string
+	walkHTML(n, func(tr *html.Node) {
+		if tr.Data != "tr" {
+			return
+		}
+		var row []string
+		for td := tr.FirstChild; td != nil; td = td.NextSibling {
+			if td.Type == html.ElementNode && (td.Data == "td" || td.Data == "th") {
+				row = append(row, strings.ReplaceAll(normMarkdownInline(c.inlineChildren(td), "<br>"), "|", `\|`))
+			}
+		}
+		rows = append(rows, row)
+	})
+	cols := 0
+	for _, row := range rows {
+		cols = max(cols, len(row))
+	}
+	if cols == 0 {
+		return ""
+	}
+	var lines []string
+	for i, row := range rows {
+		for len(row) < cols {
+			row = append(row, "")
+		}
+		lines = append(lines, "| "+strings.Join(row, " | ")+" |")
+		if i == 0 {
+			lines = append(lines, "|"+strings.Repeat(" --- |", cols))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (c *markdownConverter) inlineChildren(n *html.Node) string {
+	var sb strings.Builder
+	for ch := n.FirstChild; ch != nil; ch = ch.NextSibling {
+		sb.WriteString(c.inline(ch))
+	}
+	return sb.String()
+}
+
+// mdEmphasis wraps content with markers keeping surrounding spaces outside of markers
+func mdEmphasis(s, marker string) string {
+	trimmed := strings.TrimSpace(s)
+	if trimmed == "" {
+		return s
+	}
+	start := strings.Index(s, trimmed)
+	return s[:start] + marker + trimmed + marker + s[start+len(trimmed):]
+}
+
+func (c *markdownConverter) inline(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		return mdEscaper.Replace(reMdSpaces.ReplaceAllString(n.Data, " "))
+	case html.ElementNode:
+	default:
+		return ""
+	}
+	if isMarkdownSkipped(n) {
+		return ""
+	}
+	switch n.Data {
+	case "br":
+		return mdHardBreak
+
+	case "strong", "b":
+		return mdEmphasis(c.inlineChildren(n), "**")
+
+	case "em", "i":
+		return mdEmphasis(c.inlineChildren(n), "*")
+
+	case "del", "s", "strike":
+		return mdEmphasis(c.inlineChildren(n), "~~")
+
+	case "code", "kbd", "samp", "tt":
+		code := reMdSpaces.ReplaceAllString(htmlNodeRawText(n), " ")
+		if strings.TrimSpace(code) == "" {
+			return code
+		}
+		fence := "`"
+		for strings.Contains(code, fence) {
+			fence += "`"
+		}
+		if strings.HasPrefix(code, "`") || strings.HasSuffix(code, "`") {
+			code = " " + code + " "
+		}
+		return fence + code + fence
+
+	case "a":
+		text := strings.TrimSpace(strings.ReplaceAll(c.inlineChildren(n), mdHardBreak, " "))
+		href := strings.TrimSpace(htmlAttr(n, "href"))
+		if href == "" || strings.HasPrefix(strings.ToLower(href), "javascript:") || text == "" {
+			return text
+		}
+		return "[" + text + "](" + c.destination(href) + c.title(n) + ")"
+
+	case "img":
+		src := strings.TrimSpace(htmlAttr(n, "src"))
+		if src == "" {
+			return ""
+		}
+		alt := mdEscaper.Replace(reMdSpaces.ReplaceAllString(htmlAttr(n, "alt"), " "))
+		return "![" + strings.TrimSpace(alt) + "](" + c.destination(src) + c.title(n) + ")"
+
+	default:
+		if isMarkdownBlock(n) { // block inside of inline element
+			return " " + c.inlineChildren(n) + " "
+		}
+		return c.inlineChildren(n)
+	}
+}
+
+func (c *markdownConverter) destination(href string) string {
+	href = resolveURL(c.base, href)
+	if strings.ContainsAny(href, " ()<>") {
+		return "<" + strings.NewReplacer("<", "%3C", ">", "%3E").Replace(href) + ">"
+	}
+	return href
+}
+
+func (c *markdownConverter) title(n *html.Node) string {
+	if title := strings.TrimSpace(htmlAttr(n, "title")); title != "" {
+		return ` "` + strings.ReplaceAll(title, `"`, `\"`) + `"`
+	}
+	return ""
+}
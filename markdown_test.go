@@ -0,0 +1,50 @@
+package httpdoc
+
+import "testing"
+
+func TestDocument_Markdown(t *testing.T) {
+	srv := newTestServer(t, `<html><head><title>Doc</title><style>p{}</style></head><body>
+			<h1>Hello,   <em>world</em>!</h1>
+			<p>Some <strong>bold </strong>text with <a href="/docs/intro" title="Intro">a link</a>,
+				<code>x := 1</code> and <del>old</del> 2*2 [note].<br>Next line.</p>
+			<ul>
+				<li>One</li>
+				<li>Two
+					<ol start="3"><li>Three</li><li>Four</li></ol>
+				</li>
+			</ul>
+			<blockquote><p>Quote</p><p>More</p></blockquote>
+			<pre><code class="language-go">func main() {
+	println("hi")
+}
+</code></pre>
+			<table>
+				<tr><th>Name</th><th>Value</th></tr>
+				<tr><td>a|b</td><td><img src="img/x.png" alt="X"></td></tr>
+				<tr><td>c</td></tr>
+			</table>
+			<script>alert(1)</script>
+			<p>1. not a list</p>
+			<hr>
+		</body></html>`)
+
+	doc := newDocument(srv.URL+"/page", NewClient(), nil)
+	expected := "# Hello, *world*!\n\n" +
+		"Some **bold** text with [a link](" + srv.URL + "/docs/intro \"Intro\"), `x := 1` and ~~old~~ 2\\*2 \\[note\\].  \nNext line.\n\n" +
+		"- One\n" +
+		"- Two\n" +
+		"  3. Three\n" +
+		"  4. Four\n\n" +
+		"> Quote\n>\n> More\n\n" +
+		"```go\nfunc main() {\n\tprintln(\"hi\")\n}\n```\n\n" +
+		"| Name | Value |\n" +
+		"| --- | --- |\n" +
+		"| a\\|b | ![X](" + srv.URL + "/img/x.png) |\n" +
+		"| c |  |\n\n" +
+		"1\\. not a list\n\n" +
+		"---"
+	assert(t, doc.Markdown() == expected)
+
+	assert(t, doc.GetElementsByTagName("ul").First().Markdown() == "- One\n- Two\n  3. Three\n  4. Four")
+	assert(t, doc.GetElementsByTagName("blockquote").First().Markdown() == "> Quote\n>\n> More")
+}
@@ -0,0 +1,164 @@
+package httpdoc
+
+import (
+	"iter"
+	"regexp"
+	"regexp/syntax"
+	"unicode/utf8"
+)
+
+// RegexpMatch is match of regular expression in body of document. Texts of match are slices of body (without copying).
+type RegexpMatch struct {
+	Index []int // start and end positions in body of match and of its submatches (-1 for unmatched groups)
+
+	body  []byte
+	names []string
+}
+
+// Matches returns iterator over matches of regular expression in body of document; matches are found on demand
+func (d *Document) Matches(regExp any) iter.Seq[*RegexpMatch] {
+	re := normRe(regExp)
+	body := d.Content()
+	names := re.SubexpNames()
+	return func(yield func(*RegexpMatch) bool) {
+		for loc := range matchIndexes(re, body) {
+			if !yield(&RegexpMatch{Index: loc, body: body, names: names}) {
+				return
+			}
+		}
+	}
+}
+
+// NamedMatches returns iterator over named groups ((?P<name>...)) of all matches of regular expression
+func (d *Document) NamedMatches(regExp any) iter.Seq[map[string]string] {
+	return func(yield func(map[string]string) bool) {
+		for m := range d.Matches(regExp) {
+			if !yield(m.NamedGroups()) {
+				return
+			}
+		}
+	}
+}
+
+// NamedMatch returns named groups ((?P<name>...)) of first match of regular expression (nil if there is no match)
+func (d *Document) NamedMatch(regExp any) map[string]string {
+	for m := range d.Matches(regExp) {
+		return m.NamedGroups()
+	}
+	return nil
+}
+
+// matchIndexes iterates over successive non-overlapping matches like regexp.FindAllSubmatchIndex does
+func matchIndexes(re *regexp.Regexp, b []byte) iter.Seq[[]int] {
+	return func(yield func([]int) bool) {
+		if hasContextAssertions(re) { // search in tail of text would change meaning of assertions
+			for _, loc := range re.FindAllSubmatchIndex(b, -1) {
+				if !yield(loc) {
+					return
+				}
+			}
+			return
+		}
+		for pos, prevEnd := 0, -1; pos <= len(b); {
+			loc := re.FindSubmatchIndex(b[pos:])
+			if loc == nil {
+				return
+			}
+			for i := range loc {
+				if loc[i] >= 0 {
+					loc[i] += pos
+				}
+			}
+			accept := true
+			if loc[1] == pos { // empty match
+				accept = loc[0] != prevEnd // empty match right after previous match is ignored
+				if _, width := utf8.DecodeRune(b[pos:]); width > 0 {
+					pos += width
+				} else {
+					pos = len(b) + 1
+				}
+			} else {
+				pos = loc[1]
+			}
+			prevEnd = loc[1]
+			if accept && !yield(loc) {
+				return
+			}
+		}
+	}
+}
+
+// hasContextAssertions reports whether regexp has assertions which depend on text before position of search (^, \A, \b, \B)
+func hasContextAssertions(re *regexp.Regexp) bool {
+	prog, err := syntax.Parse(re.String(), syntax.Perl)
+	if err != nil {
+		return true
+	}
+	var has func(*syntax.Regexp) bool
+	has = func(r *syntax.Regexp) bool {
+		switch r.Op {
+		case syntax.OpBeginLine, syntax.OpBeginText, syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+			return true
+		}
+		for _, sub := range r.Sub {
+			if has(sub) {
+				return true
+			}
+		}
+		return false
+	}
+	return has(prog)
+}
+
+// Start returns start position of match in body
+func (m *RegexpMatch) Start() int {
+	return m.Index[0]
+}
+
+// End returns end position of match in body
+func (m *RegexpMatch) End() int {
+	return m.Index[1]
+}
+
+// Bytes returns text of match
+func (m *RegexpMatch) Bytes() []byte {
+	return m.SubmatchBytes(0)
+}
+
+func (m *RegexpMatch) String() string {
+	return string(m.Bytes())
+}
+
+// SubmatchBytes returns text of submatch i (0 - whole match); nil if there is no such submatch
+func (m *RegexpMatch) SubmatchBytes(i int) []byte {
+	if i < 0 || 2*i+1 >= len(m.Index) || m.Index[2*i] < 0 {
+		return nil
+	}
+	return m.body[m.Index[2*i]:m.Index[2*i+1]:m.Index[2*i+1]]
+}
+
+// Submatch returns text of submatch i (0 - whole match); "" if there is no such submatch
+func (m *RegexpMatch) Submatch(i int) string {
+	return string(m.SubmatchBytes(i))
+}
+
+// Named returns text of named group (?P<name>...)
+func (m *RegexpMatch) Named(name string) string {
+	for i, s := range m.names {
+		if s == name && s != "" {
+			return m.Submatch(i)
+		}
+	}
+	return ""
+}
+
+// NamedGroups returns texts of all named groups of match
+func (m *RegexpMatch) NamedGroups() map[string]string {
+	groups := map[string]string{}
+	for i, name := range m.names {
+		if name != "" {
+			groups[name] = m.Submatch(i)
+		}
+	}
+	return groups
+}
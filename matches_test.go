@@ -0,0 +1,72 @@
+package httpdoc
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+	"testing"
+)
+
+func TestMatchIndexes(t *testing.T) {
+	text := []byte("abc ж 12 x-3, привет\nline 2")
+	for _, expr := range []string{`\d+`, `x*`, `(a)|(b)`, `\w+`, `[а-я]*`, `^\w+`, `(?m)^\w+`, `\b\d`, `$`, `.`, `[^,]+`} {
+		re := regexp.MustCompile(expr)
+		var locs [][]int
+		for loc := range matchIndexes(re, text) {
+			locs = append(locs, loc)
+		}
+		assert(t, fmt.Sprint(locs) == fmt.Sprint(re.FindAllSubmatchIndex(text, -1)))
+	}
+}
+
+func TestHasContextAssertions(t *testing.T) {
+	for expr, has := range map[string]bool{`^a`: true, `(?m)^a`: true, `\Aa`: true, `\ba`: true, `a\B`: true,
+		`[^,]+`: false, `\^`: false, `a$`: false, `\d+`: false} {
+		assert(t, hasContextAssertions(regexp.MustCompile(expr)) == has)
+	}
+}
+
+func TestDocument_Matches(t *testing.T) {
+	srv := newTestServer(t, `<li>ABC-1: $10.5</li><li>XYZ-22: $7</li><li>QQQ-3: n/a</li>`)
+
+	doc := newDocument(srv.URL, NewClient(), nil)
+	re := `(?P<sku>[A-Z]{3}-\d+): \$(?P<price>[\d.]+)`
+
+	var res []string
+	for m := range doc.Matches(re) {
+		res = append(res, fmt.Sprintf("%d-%d %s %s %q", m.Start(), m.End(), m.Named("sku"), m.Submatch(2), m.Submatch(3)))
+	}
+	assert(t, fmt.Sprint(res) == `[4-16 ABC-1 10.5 "" 25-35 XYZ-22 7 ""]`)
+
+	var prices []string
+	for g := range doc.NamedMatches(re) {
+		prices = append(prices, g["price"])
+	}
+	assert(t, fmt.Sprint(prices) == "[10.5 7]")
+	assert(t, fmt.Sprint(doc.NamedMatch(re)) == "map[price:10.5 sku:ABC-1]")
+	assert(t, doc.NamedMatch(`none(?P<x>\d)`) == nil)
+
+	// bounds of submatches
+	assert(t, doc.Submatch(re, 5) == "" && doc.Submatch(re, -1) == "")
+	assert(t, doc.AllSubmatches(re, 3) == nil)
+	assert(t, fmt.Sprint(doc.AllSubmatches(re, 1)) == "[ABC-1 XYZ-22]")
+
+	// content string is cached until body is replaced
+	s := doc.ContentStr()
+	assert(t, doc.ContentStr() == s)
+	doc.Body = []byte("new body")
+	assert(t, doc.ContentStr() == "new body")
+}
+
+func TestDocument_ContentStrConcurrent(t *testing.T) {
+	doc := NewDocument("https://example.com/").SetResponse(newHTMLResponse(), []byte(`<p>price: 10</p>`))
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert(t, doc.Submatch(`price: (\d+)`, 1) == "10")
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,410 @@
+package httpdoc
+
+import (
+	"encoding/json"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/goldic/js"
+	"golang.org/x/net/html"
+)
+
+// Metadata is structured meta-information of html-document
+type Metadata struct {
+	Title       string
+	Description string
+	Canonical   string
+	OpenGraph   MetaProperties // og:*, article:*, product:*, fb:*, etc
+	Twitter     MetaProperties // twitter:*
+	DublinCore  MetaProperties // dc.*, dcterms.*
+	Meta        MetaProperties // other <meta name="..." content="...">
+	Alternates  []MetaLink     // <link rel="alternate" hreflang="...">
+	Feeds       []MetaLink     // RSS, Atom and JSON feeds
+	JSONLD      []js.Object    // <script type="application/ld+json">
+	Microdata   []*MetaItem    // schema.org microdata items (itemscope, itemtype, itemprop)
+	RDFa        []*MetaItem    // RDFa items (vocab, typeof, property)
+}
+
+// MetaProperties is map of meta-properties (names are in lower case) to values
+type MetaProperties map[string][]string
+
+// MetaLink is <link>-tag of html-document
+type MetaLink struct {
+	Href     string
+	Type     string
+	Title    string
+	HrefLang string
+}
+
+// MetaItem is Microdata or RDFa item
+type MetaItem struct {
+	Type       []string
+	ID         string
+	Properties map[string][]any // values are strings or *MetaItem
+}
+
+// Get returns first value of property
+func (p MetaProperties) Get(name string) string {
+	if vv := p[strings.ToLower(name)]; len(vv) > 0 {
+		return vv[0]
+	}
+	return ""
+}
+
+func (p MetaProperties) add(name, value string) {
+	name = strings.ToLower(name)
+	p[name] = append(p[name], value)
+}
+
+// Get returns first string value of property
+func (it *MetaItem) Get(name string) string {
+	for _, v := range it.Properties[name] {
+		if s, ok := v.(string); ok {
+			return s
+		}
+	}
+	return ""
+}
+
+// Item returns first nested item of property
+func (it *MetaItem) Item(name string) *MetaItem {
+	for _, v := range it.Properties[name] {
+		if item, ok := v.(*MetaItem); ok {
+			return item
+		}
+	}
+	return nil
+}
+
+// Metadata gets structured meta-information of html-document. Urls are resolved to absolute.
+func (d *Document) Metadata() *Metadata {
+	m := &Metadata{
+		OpenGraph:  MetaProperties{},
+		Twitter:    MetaProperties{},
+		DublinCore: MetaProperties{},
+		Meta:       MetaProperties{},
+	}
+	root := d.htmlRoot()
+	base := d.BaseURL()
+	ids := map[string]*html.Node{}
+	walkHTML(root, func(n *html.Node) {
+		if id := htmlAttr(n, "id"); id != "" {
+			ids[id] = n
+		}
+	})
+	walkHTML(root, func(n *html.Node) {
+		switch n.Data {
+		case "title":
+			if m.Title == "" {
+				m.Title = htmlNodeText(n)
+			}
+		case "meta":
+			m.addMeta(n, base)
+		case "link":
+			m.addLink(n, base)
+		case "script":
+			if strings.EqualFold(strings.TrimSpace(htmlAttr(n, "type")), "application/ld+json") {
+				m.addJSONLD(htmlNodeRawText(n))
+			}
+		}
+		if hasHTMLAttr(n, "itemscope") && !hasHTMLAttr(n, "itemprop") {
+			m.Microdata = append(m.Microdata, newMicrodataItem(n, ids, base, map[*html.Node]bool{}))
+		}
+		if hasHTMLAttr(n, "typeof") && !hasHTMLAttr(n, "property") && !hasRDFaItemParent(n) {
+			m.RDFa = append(m.RDFa, newRDFaItem(n, rdfaVocab(n), base))
+		}
+	})
+	if m.Description == "" {
+		m.Description = m.OpenGraph.Get("og:description")
+	}
+	return m
+}
+
+// metaURLProperties are meta-properties containing urls
+var metaURLProperties = map[string]bool{
+	"og:url":                true,
+	"og:image":              true,
+	"og:image:url":          true,
+	"og:image:secure_url":   true,
+	"og:video":              true,
+	"og:video:url":          true,
+	"og:video:secure_url":   true,
+	"og:audio":              true,
+	"og:audio:url":          true,
+	"og:audio:secure_url":   true,
+	"twitter:image":         true,
+	"twitter:image:src":     true,
+	"twitter:player":        true,
+	"twitter:player:stream": true,
+}
+
+func (m *Metadata) addMeta(n *html.Node, base *url.URL) {
+	content, hasContent := getHTMLAttr(n, "content")
+	if !hasContent {
+		return
+	}
+	name := strings.TrimSpace(htmlAttr(n, "name"))
+	property := strings.TrimSpace(htmlAttr(n, "property"))
+	key := strings.ToLower(name)
+	if key == "" {
+		key = strings.ToLower(property)
+	}
+	if metaURLProperties[key] {
+		content = resolveURL(base, content)
+	}
+	switch {
+	case key == "":
+	case strings.HasPrefix(key, "twitter:"):
+		m.Twitter.add(key, content)
+	case strings.HasPrefix(key, "dc.") || strings.HasPrefix(key, "dcterms.") || strings.HasPrefix(key, "dc:") || strings.HasPrefix(key, "dcterms:"):
+		m.DublinCore.add(key, content)
+	case property != "" && strings.Contains(key, ":"):
+		m.OpenGraph.add(key, content)
+	case name != "":
+		m.Meta.add(key, content)
+		if key == "description" && m.Description == "" {
+			m.Description = strings.TrimSpace(content)
+		}
+	}
+}
+
+func (m *Metadata) addLink(n *html.Node, base *url.URL) {
+	href := resolveURL(base, htmlAttr(n, "href"))
+	if href == "" {
+		return
+	}
+	rel := strings.Fields(strings.ToLower(htmlAttr(n, "rel")))
+	link := MetaLink{
+		Href:     href,
+		Type:     strings.ToLower(strings.TrimSpace(htmlAttr(n, "type"))),
+		Title:    htmlAttr(n, "title"),
+		HrefLang: htmlAttr(n, "hreflang"),
+	}
+	switch {
+	case slices.Contains(rel, "canonical"):
+		if m.Canonical == "" {
+			m.Canonical = href
+		}
+	case !slices.Contains(rel, "alternate"):
+	case link.Type == "application/rss+xml" || link.Type == "application/atom+xml" || link.Type == "application/feed+json":
+		m.Feeds = append(m.Feeds, link)
+	case link.HrefLang != "":
+		m.Alternates = append(m.Alternates, link)
+	}
+}
+
+func (m *Metadata) addJSONLD(src string) {
+	data, err := JSToJSON(trimHTMLComment(src))
+	if err != nil {
+		return
+	}
+	var v any
+	if json.Unmarshal(data, &v) != nil {
+		return
+	}
+	switch v := v.(type) {
+	case map[string]any:
+		m.JSONLD = append(m.JSONLD, v)
+	case []any:
+		for _, o := range v {
+			if obj, ok := o.(map[string]any); ok {
+				m.JSONLD = append(m.JSONLD, obj)
+			}
+		}
+	}
+}
+
+//----------- microdata -------------
+
+// newMicrodataItem makes item of node with itemscope; path is set of items being made (for cycles of itemref)
+func newMicrodataItem(n *html.Node, ids map[string]*html.Node, base *url.URL, path map[*html.Node]bool) *MetaItem {
+	path[n] = true
+	defer delete(path, n)
+	item := &MetaItem{
+		Type:       strings.Fields(htmlAttr(n, "itemtype")),
+		ID:         strings.TrimSpace(htmlAttr(n, "itemid")),
+		Properties: map[string][]any{},
+	}
+	var visit func(n *html.Node)
+	visit = func(n *html.Node) {
+		if path[n] {
+			return
+		}
+		if props := strings.Fields(htmlAttr(n, "itemprop")); len(props) > 0 {
+			var value any
+			if hasHTMLAttr(n, "itemscope") {
+				value = newMicrodataItem(n, ids, base, path)
+			} else {
+				value = microdataValue(n, base)
+			}
+			for _, prop := range props {
+				item.Properties[prop] = append(item.Properties[prop], value)
+			}
+		}
+		if !hasHTMLAttr(n, "itemscope") {
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				if c.Type == html.ElementNode {
+					visit(c)
+				}
+			}
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode {
+			visit(c)
+		}
+	}
+	// properties of elements referenced by itemref
+	for _, id := range strings.Fields(htmlAttr(n, "itemref")) {
+		if ref := ids[id]; ref != nil && ref != n {
+			visit(ref)
+		}
+	}
+	return item
+}
+
+func microdataValue(n *html.Node, base *url.URL) string {
+	switch n.Data {
+	case "meta":
+		return htmlAttr(n, "content")
+	case "audio", "embed", "iframe", "img", "source", "track", "video":
+		return resolveURL(base, htmlAttr(n, "src"))
+	case "a", "area", "link":
+		return resolveURL(base, htmlAttr(n, "href"))
+	case "object":
+		return resolveURL(base, htmlAttr(n, "data"))
+	case "data", "meter":
+		return htmlAttr(n, "value")
+	case "time":
+		if v, ok := getHTMLAttr(n, "datetime"); ok {
+			return v
+		}
+	}
+	return htmlNodeText(n)
+}
+
+//----------- RDFa -------------
+
+func rdfaVocab(n *html.Node) string {
+	for ; n != nil; n = n.Parent {
+		if v, ok := getHTMLAttr(n, "vocab"); ok {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
+
+func hasRDFaItemParent(n *html.Node) bool {
+	for p := n.Parent; p != nil; p = p.Parent {
+		if hasHTMLAttr(p, "typeof") {
+			return true
+		}
+	}
+	return false
+}
+
+func newRDFaItem(n *html.Node, vocab string, base *url.URL) *MetaItem {
+	if v, ok := getHTMLAttr(n, "vocab"); ok {
+		vocab = strings.TrimSpace(v)
+	}
+	item := &MetaItem{
+		ID:         strings.TrimSpace(htmlAttr(n, "resource")),
+		Properties: map[string][]any{},
+	}
+	for _, typ := range strings.Fields(htmlAttr(n, "typeof")) {
+		if vocab != "" && !strings.Contains(typ, ":") {
+			typ = vocab + typ
+		}
+		item.Type = append(item.Type, typ)
+	}
+	var addProps func(n *html.Node)
+	addProps = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			if props := strings.Fields(htmlAttr(c, "property")); len(props) > 0 {
+				var value any
+				if hasHTMLAttr(c, "typeof") {
+					value = newRDFaItem(c, vocab, base)
+				} else {
+					value = rdfaValue(c, base)
+				}
+				for _, prop := range props {
+					item.Properties[prop] = append(item.Properties[prop], value)
+				}
+			}
+			if !hasHTMLAttr(c, "typeof") {
+				addProps(c)
+			}
+		}
+	}
+	addProps(n)
+	return item
+}
+
+func rdfaValue(n *html.Node, base *url.URL) string {
+	if v, ok := getHTMLAttr(n, "content"); ok {
+		return v
+	}
+	for _, attr := range []string{"resource", "href", "src"} {
+		if v, ok := getHTMLAttr(n, attr); ok {
+			return resolveURL(base, v)
+		}
+	}
+	if v, ok := getHTMLAttr(n, "datetime"); ok {
+		return v
+	}
+	return htmlNodeText(n)
+}
+
+//----------- html-tree helpers -------------
+
+func walkHTML(n *html.Node, fn func(*html.Node)) {
+	if n.Type == html.ElementNode {
+		fn(n)
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walkHTML(c, fn)
+	}
+}
+
+func getHTMLAttr(n *html.Node, name string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Key == name && a.Namespace == "" {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+func htmlAttr(n *html.Node, name string) string {
+	v, _ := getHTMLAttr(n, name)
+	return v
+}
+
+func hasHTMLAttr(n *html.Node, name string) bool {
+	_, ok := getHTMLAttr(n, name)
+	return ok
+}
+
+// htmlNodeText returns text content of node with collapsed white spaces
+func htmlNodeText(n *html.Node) string {
+	return strings.TrimSpace(reSpace.ReplaceAllString(htmlNodeRawText(n), " "))
+}
+
+func htmlNodeRawText(n *html.Node) string {
+	var sb strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return sb.String()
+}
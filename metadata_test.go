@@ -0,0 +1,81 @@
+package httpdoc
+
+import "testing"
+
+const testMetadataHTML = `<!DOCTYPE html>
+<html><head>
+	<title>Gopher  Shop</title>
+	<meta name="description" content="Toys for gophers">
+	<meta property="og:title" content="Gopher Plush">
+	<meta property="og:image" content="/img/1.png">
+	<meta property="og:image" content="/img/2.png">
+	<meta property="article:author" content="Rob">
+	<meta name="twitter:card" content="summary_large_image">
+	<meta name="DC.creator" content="Ken">
+	<meta name="robots" content="index,follow">
+	<link rel="canonical" href="https://shop.example.com/gopher">
+	<link rel="alternate" hreflang="de" href="https://shop.example.com/de/gopher">
+	<link rel="alternate" type="application/rss+xml" title="News" href="/feed.rss">
+	<script type="application/ld+json">[{"@context": "https://schema.org", "@type": "Product", "name": "Gopher Plush",}]</script>
+</head><body>
+	<div itemscope itemtype="https://schema.org/Product" itemref="brand">
+		<h1 itemprop="name">Gopher  Plush</h1>
+		<img itemprop="image" src="/img/1.png">
+		<div itemprop="offers" itemscope itemtype="https://schema.org/Offer">
+			<span itemprop="price">9.99</span><meta itemprop="priceCurrency" content="USD">
+		</div>
+	</div>
+	<p id="brand">by <span itemprop="brand">Go Team</span></p>
+	<div vocab="https://schema.org/" typeof="Person">
+		<span property="name">Alice</span>
+		<a property="url" href="https://alice.example.com">home</a>
+		<div property="address" typeof="PostalAddress"><span property="addressLocality">Berlin</span></div>
+	</div>
+</body></html>`
+
+func TestDocument_Metadata(t *testing.T) {
+	srv := newTestServer(t, testMetadataHTML)
+
+	m := newDocument(srv.URL, NewClient(), nil).Metadata()
+
+	assert(t, m.Title == "Gopher Shop")
+	assert(t, m.Description == "Toys for gophers")
+	assert(t, m.Canonical == "https://shop.example.com/gopher")
+	assert(t, m.OpenGraph.Get("og:title") == "Gopher Plush")
+	assert(t, len(m.OpenGraph["og:image"]) == 2 && m.OpenGraph.Get("og:image") == srv.URL+"/img/1.png")
+	assert(t, m.OpenGraph.Get("article:author") == "Rob")
+	assert(t, m.Twitter.Get("twitter:card") == "summary_large_image")
+	assert(t, m.DublinCore.Get("dc.creator") == "Ken")
+	assert(t, m.Meta.Get("robots") == "index,follow")
+	assert(t, len(m.Alternates) == 1 && m.Alternates[0].HrefLang == "de")
+	assert(t, len(m.Feeds) == 1 && m.Feeds[0].Href == srv.URL+"/feed.rss" && m.Feeds[0].Title == "News")
+	assert(t, len(m.JSONLD) == 1 && m.JSONLD[0].GetStr("@type") == "Product")
+
+	assert(t, len(m.Microdata) == 1)
+	product := m.Microdata[0]
+	assert(t, product.Type[0] == "https://schema.org/Product")
+	assert(t, product.Get("name") == "Gopher Plush")
+	assert(t, product.Get("image") == srv.URL+"/img/1.png")
+	assert(t, product.Get("brand") == "Go Team")
+	assert(t, product.Item("offers").Get("price") == "9.99")
+	assert(t, product.Item("offers").Get("priceCurrency") == "USD")
+
+	assert(t, len(m.RDFa) == 1)
+	person := m.RDFa[0]
+	assert(t, person.Type[0] == "https://schema.org/Person")
+	assert(t, person.Get("name") == "Alice")
+	assert(t, person.Get("url") == "https://alice.example.com")
+	assert(t, person.Item("address").Get("addressLocality") == "Berlin")
+	assert(t, person.Item("address").Type[0] == "https://schema.org/PostalAddress")
+}
+
+func TestDocument_Metadata_itemrefCycle(t *testing.T) {
+	doc := NewDocument("https://example.com/").SetResponse(newHTMLResponse(), []byte(
+		`<div itemscope><div id="a" itemprop="x" itemscope><div itemprop="y" itemscope itemref="a"></div></div></div>`))
+
+	m := doc.Metadata()
+
+	assert(t, len(m.Microdata) == 1)
+	assert(t, m.Microdata[0].Item("x").Item("y") != nil)
+	assert(t, m.Microdata[0].Item("x").Item("y").Item("x") == nil)
+}
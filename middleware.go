@@ -1,9 +1,180 @@
 package httpdoc
 
-type middlewareFunc func(*Document) error
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
 
-var middlewares []middlewareFunc
+// Middleware wraps loading of document; calling next sends request and reads response
+type Middleware func(d *Document, next func() error) error
 
-func AddMiddleware(fn middlewareFunc) {
-	middlewares = append(middlewares, fn)
+// BeforeRequest creates middleware that calls fn before sending request
+func BeforeRequest(fn func(*Document) error) Middleware {
+	return func(d *Document, next func() error) error {
+		if err := fn(d); err != nil {
+			return err
+		}
+		return next()
+	}
+}
+
+// AfterResponse creates middleware that calls fn after response is read and decoded
+func AfterResponse(fn func(*Document) error) Middleware {
+	return func(d *Document, next func() error) error {
+		if err := next(); err != nil {
+			return err
+		}
+		return fn(d)
+	}
+}
+
+// Retry creates middleware that resends request after connection errors and responses with status 429 or 5xx.
+// Delay is doubled after each attempt; Retry-After header of response is respected.
+func Retry(maxRetries int, delay time.Duration) Middleware {
+	return func(d *Document, next func() error) (err error) {
+		for i := 0; ; i++ {
+			err = next()
+			if i >= maxRetries || !isRetryable(d, err) {
+				return
+			}
+			pause := delay << i
+			if d.Response != nil {
+				if sec, _ := strconv.Atoi(d.Response.Header.Get("Retry-After")); sec > 0 {
+					pause = time.Duration(sec) * time.Second
+				}
+			}
+			time.Sleep(pause)
+		}
+	}
+}
+
+func isRetryable(d *Document, err error) bool {
+	if err != nil {
+		return d.Request.Body == nil || d.Request.GetBody != nil
+	}
+	status := d.Response.StatusCode
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+// Middlewares is a chain of middlewares, safe for concurrent use.
+type Middlewares struct {
+	mx   sync.RWMutex
+	list []*Middleware
+}
+
+// Use adds middleware to the end of chain and returns function that removes it from chain
+func (m *Middlewares) Use(fn Middleware) (remove func()) {
+	p := &fn
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.list = append(slices.Clip(m.list), p) // copy on write
+	return func() {
+		m.mx.Lock()
+		defer m.mx.Unlock()
+		if i := slices.Index(m.list, p); i >= 0 {
+			m.list = slices.Concat(m.list[:i], m.list[i+1:])
+		}
+	}
+}
+
+// Clear removes all middlewares from chain
+func (m *Middlewares) Clear() {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.list = nil
+}
+
+func (m *Middlewares) Len() int {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	return len(m.list)
+}
+
+func (m *Middlewares) funcs() []*Middleware {
+	if m == nil {
+		return nil
+	}
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	return m.list
+}
+
+// GlobalMiddlewares are applied to all documents in the process
+var GlobalMiddlewares = &Middlewares{}
+
+// AddMiddleware adds global handler of loaded documents.
+//
+// Deprecated: use GlobalMiddlewares.Use(AfterResponse(fn)) or scoped middlewares.
+func AddMiddleware(fn func(*Document) error) {
+	GlobalMiddlewares.Use(AfterResponse(fn))
+}
+
+// middlewareTransport binds middlewares to http-client
+type middlewareTransport struct {
+	http.RoundTripper
+	middlewares *Middlewares
+}
+
+var clientMiddlewaresMx sync.Mutex
+
+// ClientMiddlewares returns middlewares applied to all documents loaded by the client.
+// The first call wraps client transport, so it should be done before the client is used.
+func ClientMiddlewares(c *http.Client) *Middlewares {
+	clientMiddlewaresMx.Lock()
+	defer clientMiddlewaresMx.Unlock()
+	if t, ok := c.Transport.(*middlewareTransport); ok {
+		return t.middlewares
+	}
+	tr := c.Transport
+	if tr == nil {
+		tr = http.DefaultTransport
+	}
+	t := &middlewareTransport{tr, &Middlewares{}}
+	c.Transport = t
+	return t.middlewares
+}
+
+func clientMiddlewares(c *http.Client) *Middlewares {
+	if c != nil {
+		if t, ok := c.Transport.(*middlewareTransport); ok {
+			return t.middlewares
+		}
+	}
+	return nil
+}
+
+// Use adds middleware to the document
+func (d *Document) Use(fn Middleware) *Document {
+	d.middlewares.Use(fn)
+	return d
+}
+
+func (d *Document) handleMiddlewares(send func() error) error {
+	var chain []*Middleware
+	chain = append(chain, GlobalMiddlewares.funcs()...)
+	chain = append(chain, clientMiddlewares(d.Client).funcs()...)
+	if d.session != nil {
+		chain = append(chain, d.session.middlewares.funcs()...)
+	}
+	chain = append(chain, d.middlewares.funcs()...)
+	return d.callMiddlewares(chain, send)
+}
+
+func (d *Document) callMiddlewares(chain []*Middleware, send func() error) (err error) {
+	if len(chain) == 0 {
+		return send()
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("httpdoc.Document.Load-PANIC: %v", r)
+		}
+	}()
+	fn := *chain[0]
+	return fn(d, func() error {
+		return d.callMiddlewares(chain[1:], send)
+	})
 }
@@ -0,0 +1,67 @@
+package httpdoc
+
+import (
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+)
+
+func TestMiddlewares(t *testing.T) {
+	var requests int32
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("<title>" + r.Header.Get("X-Token") + r.PostFormValue("q") + "</title>"))
+	})
+
+	client := NewClient()
+	removeClientMW := ClientMiddlewares(client).Use(BeforeRequest(func(d *Document) error {
+		d.SetHeader("X-Token", "token-")
+		return nil
+	}))
+
+	retry := func(d *Document, next func() error) error {
+		if err := next(); err != nil || d.Response.StatusCode < 500 {
+			return err
+		}
+		return next() // replay request
+	}
+	doc := newDocument(srv.URL, client, nil).SetPOSTParam("q", "query").Use(retry)
+
+	assert(t, doc.Load() == nil)
+	assert(t, "token-query" == doc.Title())
+	assert(t, 2 == atomic.LoadInt32(&requests))
+
+	// short-circuit
+	removeClientMW()
+	doc = newDocument(srv.URL, client, nil).Use(func(d *Document, next func() error) error {
+		d.SetResponse(&http.Response{StatusCode: 200}, []byte("<title>cached</title>"))
+		return nil
+	})
+	assert(t, doc.Load() == nil)
+	assert(t, "cached" == doc.Title())
+	assert(t, 2 == atomic.LoadInt32(&requests))
+
+	// error in after-response hook
+	doc = newDocument(srv.URL, client, nil).Use(AfterResponse(func(d *Document) error {
+		return errors.New("rejected")
+	}))
+	assert(t, doc.Load().Error() == "rejected")
+}
+
+func TestRetry_cookies(t *testing.T) {
+	var cookies []string
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if cookies = append(cookies, r.Header.Get("Cookie")); len(cookies) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	})
+
+	doc := newDocument(srv.URL, NewClient(), nil).Use(Retry(2, 0))
+	doc.SetCookie(&http.Cookie{Name: "sid", Value: "1", Path: "/"})
+	assert(t, doc.Load() == nil)
+	assert(t, len(cookies) == 3 && cookies[0] == "sid=1" && cookies[1] == "sid=1" && cookies[2] == "sid=1")
+}
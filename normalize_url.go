@@ -0,0 +1,202 @@
+package httpdoc
+
+import (
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// NormalizeOptions are options of url normalization (see NormalizeURL). Zero value enables all normalizations.
+type NormalizeOptions struct {
+	KeepFragment       bool     // don't remove fragment (#...)
+	KeepTrailingSlash  bool     // don't remove trailing slash of path ("/dir/" -> "/dir")
+	KeepQueryOrder     bool     // don't sort query params
+	KeepTrackingParams bool     // don't remove tracking params (utm_*, fbclid, gclid, etc)
+	TrackingParams     []string // additional names of tracking params; names ending with "*" are prefixes
+}
+
+// TrackingParams are names of query params which are removed by NormalizeURL; names ending with "*" are prefixes
+var TrackingParams = []string{
+	"utm_*", "fbclid", "gclid", "gclsrc", "dclid", "msclkid", "yclid", "mc_cid", "mc_eid", "igshid", "_hsenc", "_hsmi",
+}
+
+var defaultPorts = map[string]string{"http": "80", "https": "443", "ws": "80", "wss": "443", "ftp": "21"}
+
+// NormalizeURL returns canonical form of url for de-duplication (lower-case punycode host, sorted query without tracking params,
+// without default port, fragment and trailing slash, etc)
+func NormalizeURL(rawURL string, opts NormalizeOptions) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return "", err
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Opaque != "" { // mailto:, tel:, etc
+		return u.String(), nil
+	}
+	if u.Host != "" {
+		host, port := strings.TrimSuffix(strings.ToLower(u.Hostname()), "."), u.Port()
+		if !isASCII(host) {
+			if host, err = idna.ToASCII(host); err != nil {
+				return "", err
+			}
+		}
+		if port == defaultPorts[u.Scheme] {
+			port = ""
+		}
+		if port != "" {
+			host = net.JoinHostPort(host, port)
+		} else if strings.Contains(host, ":") { // ipv6
+			host = "[" + host + "]"
+		}
+		u.Host = host
+	}
+
+	// path
+	p := removeDotSegments(normPercentEncoding(u.EscapedPath()))
+	if p == "" && u.Host != "" {
+		p = "/"
+	}
+	if !opts.KeepTrailingSlash && len(p) > 1 {
+		p = strings.TrimRight(p, "/")
+	}
+	if u.Path, err = url.PathUnescape(p); err != nil {
+		return "", err
+	}
+	u.RawPath = p
+
+	// query
+	var params []string
+	for _, param := range strings.Split(u.RawQuery, "&") {
+		if param == "" {
+			continue
+		}
+		name, value, hasValue := strings.Cut(param, "=")
+		if name, err = url.QueryUnescape(name); err != nil {
+			return "", err
+		}
+		if value, err = url.QueryUnescape(value); err != nil {
+			return "", err
+		}
+		if !opts.KeepTrackingParams && (isTrackingParam(name, TrackingParams) || isTrackingParam(name, opts.TrackingParams)) {
+			continue
+		}
+		if param = url.QueryEscape(name); hasValue {
+			param += "=" + url.QueryEscape(value)
+		}
+		params = append(params, param)
+	}
+	if !opts.KeepQueryOrder {
+		slices.SortStableFunc(params, func(a, b string) int {
+			a, _, _ = strings.Cut(a, "=")
+			b, _, _ = strings.Cut(b, "=")
+			return strings.Compare(a, b)
+		})
+	}
+	u.RawQuery, u.ForceQuery = strings.Join(params, "&"), false
+
+	if !opts.KeepFragment {
+		u.Fragment, u.RawFragment = "", ""
+	}
+	return u.String(), nil
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+func isTrackingParam(name string, params []string) bool {
+	name = strings.ToLower(name)
+	for _, p := range params {
+		if p = strings.ToLower(p); p == name {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(p, "*"); ok && strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// normPercentEncoding upper-cases hex digits of percent-encoding and decodes unreserved characters (RFC 3986)
+func normPercentEncoding(s string) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' && i+2 < len(s) && isHex(s[i+1]) && isHex(s[i+2]) {
+			c := unhex(s[i+1])<<4 | unhex(s[i+2])
+			if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '.' || c == '_' || c == '~' {
+				sb.WriteByte(c)
+			} else {
+				sb.WriteString(strings.ToUpper(s[i : i+3]))
+			}
+			i += 2
+			continue
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
+
+func isHex(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case c >= 'a':
+		return c - 'a' + 10
+	case c >= 'A':
+		return c - 'A' + 10
+	}
+	return c - '0'
+}
+
+// removeDotSegments resolves "." and ".." segments of path (RFC 3986, section 5.2.4)
+func removeDotSegments(p string) string {
+	if !strings.Contains(p, ".") {
+		return p
+	}
+	var out []string
+	segments := strings.Split(p, "/")
+	for i, seg := range segments {
+		last := i == len(segments)-1
+		switch seg {
+		case ".":
+			if last {
+				out = append(out, "")
+			}
+		case "..":
+			if len(out) > 1 || len(out) == 1 && out[0] != "" {
+				out = out[:len(out)-1]
+			}
+			if last {
+				out = append(out, "")
+			}
+		default:
+			out = append(out, seg)
+		}
+	}
+	res := strings.Join(out, "/")
+	if strings.HasPrefix(p, "/") && !strings.HasPrefix(res, "/") {
+		res = "/" + res
+	}
+	return res
+}
+
+// visitedKey returns key of url for sets of visited pages
+func visitedKey(u *url.URL) string {
+	if s, err := NormalizeURL(u.String(), NormalizeOptions{}); err == nil {
+		return s
+	}
+	return u.String()
+}
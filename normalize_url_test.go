@@ -0,0 +1,37 @@
+package httpdoc
+
+import "testing"
+
+func TestNormalizeURL(t *testing.T) {
+	for src, expected := range map[string]string{
+		"HTTP://Пример.РФ:80/a/./b/../c/?utm_source=x&b=2&a=1#top": "http://xn--e1afmkfd.xn--p1ai/a/c?a=1&b=2",
+		"https://Example.com:443":                                  "https://example.com/",
+		"https://example.com:8443/":                                "https://example.com:8443/",
+		"http://[::1]:80/x/":                                       "http://[::1]/x",
+		"http://[::1]:8080/x":                                      "http://[::1]:8080/x",
+		"http://a.com/%7euser/%2f%e2%82%ac?q=%7e%2a":               "http://a.com/~user/%2F%E2%82%AC?q=~%2A",
+		"http://a.com/../../x/./y/..":                              "http://a.com/x",
+		"http://a.com/p?fbclid=1&gclid=2&UTM_Medium=3&z=1&a=2&a=1": "http://a.com/p?a=2&a=1&z=1",
+		"http://a.com/?":                                           "http://a.com/",
+		"mailto:Bob@Example.com":                                   "mailto:Bob@Example.com",
+		"http://example.com/a b?q=a b":                             "http://example.com/a%20b?q=a+b",
+		"http://example.com/?q=a+b":                                "http://example.com/?q=a+b",
+		"http://example.com/?q=a%20b&flag&x=%e2%82%ac":             "http://example.com/?flag&q=a+b&x=%E2%82%AC",
+	} {
+		u, err := NormalizeURL(src, NormalizeOptions{})
+		assert(t, err == nil && u == expected)
+	}
+
+	u, err := NormalizeURL("http://a.com/dir/?utm_id=1&sid=2&b=1&a=2#x", NormalizeOptions{
+		KeepFragment:      true,
+		KeepTrailingSlash: true,
+		KeepQueryOrder:    true,
+		TrackingParams:    []string{"sid"},
+	})
+	assert(t, err == nil && u == "http://a.com/dir/?b=1&a=2#x")
+
+	_, err = NormalizeURL("http://a b.com/%zz", NormalizeOptions{})
+	assert(t, err != nil)
+	_, err = NormalizeURL("http://a.com/?q=%zz", NormalizeOptions{})
+	assert(t, err != nil)
+}
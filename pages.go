@@ -0,0 +1,147 @@
+package httpdoc
+
+import (
+	"bytes"
+	"fmt"
+	"iter"
+	"strconv"
+)
+
+// NextPageFunc returns next page of document or nil if document is the last page
+type NextPageFunc func(d *Document) *Document
+
+// Pages returns iterator over document and its next pages found by rel="next" (nil), css-selector or function.
+// Optional maxPages limits number of pages. Iteration stops on repeated url, empty page (or the same content as previous page)
+// and on error of loading; the page is yielded with the error since Document has no error of loading to check it by.
+func (d *Document) Pages(next any, maxPages ...int) iter.Seq2[*Document, error] {
+	nextPage := normNextPage(next)
+	limit := 0
+	if len(maxPages) > 0 {
+		limit = maxPages[0]
+	}
+	return func(yield func(*Document, error) bool) {
+		visited := map[string]bool{}
+		var prevBody []byte
+		for doc, n := d, 0; doc != nil && (limit <= 0 || n < limit); n++ {
+			key := visitedKey(doc.URL())
+			if visited[key] {
+				return
+			}
+			visited[key] = true
+			if err := doc.Load(); err != nil {
+				yield(doc, err)
+				return
+			}
+			visited[visitedKey(doc.URL())] = true // url after redirects
+			if isEmptyPage(doc.Body) || n > 0 && bytes.Equal(doc.Body, prevBody) {
+				return
+			}
+			if !yield(doc, nil) {
+				return
+			}
+			prevBody, doc = doc.Body, nextPage(doc)
+		}
+	}
+}
+
+func normNextPage(next any) NextPageFunc {
+	switch fn := next.(type) {
+	case nil:
+		return nextPageBySelector(`link[rel~="next"][href], a[rel~="next"][href]`)
+
+	case string:
+		if fn == "" {
+			return normNextPage(nil)
+		}
+		return nextPageBySelector(fn)
+
+	case NextPageFunc:
+		return fn
+
+	case func(*Document) *Document:
+		return fn
+
+	case func(*Document) string:
+		return func(d *Document) *Document {
+			if href := fn(d); href != "" {
+				return d.NewDoc(href)
+			}
+			return nil
+		}
+
+	default:
+		panic(fmt.Sprintf("Unknown format of next page (%v)", next))
+	}
+}
+
+func nextPageBySelector(selector string) NextPageFunc {
+	return func(d *Document) *Document {
+		if e := d.Find(selector).FilterByAttr("href").First(); e != nil {
+			return d.NewDoc(e.Attributes["href"])
+		}
+		return nil
+	}
+}
+
+// isEmptyPage reports whether body of page is empty (or is empty json)
+func isEmptyPage(body []byte) bool {
+	switch string(bytes.TrimSpace(body)) {
+	case "", "[]", "{}", "null":
+		return true
+	}
+	return false
+}
+
+// NonEmptyPages returns function of next page which stops pagination on page without elements matching itemsSelector
+// (e.g. "no results" page of html-listing past the last page)
+func NonEmptyPages(next any, itemsSelector string) NextPageFunc {
+	nextPage := normNextPage(next)
+	return func(d *Document) *Document {
+		doc := nextPage(d)
+		if doc != nil && doc.Load() == nil && len(doc.Find(itemsSelector)) == 0 {
+			return nil
+		}
+		return doc
+	}
+}
+
+// PageParam returns function of next page which increments query param of url ("?page=2" -> "?page=3").
+// Html-sites may never end, so set maxPages or use NonEmptyPages.
+func PageParam(name string) NextPageFunc {
+	return func(d *Document) *Document {
+		num, err := strconv.Atoi(d.URL().Query().Get(name))
+		if err != nil {
+			num = 1
+		}
+		u := *d.URL()
+		q := u.Query()
+		q.Set(name, strconv.Itoa(num+1))
+		u.RawQuery = q.Encode()
+		return d.NewDoc(u.String())
+	}
+}
+
+// JSONCursor returns function of next page which passes cursor found by json-path to query param (or as url if param is empty)
+func JSONCursor(param, path string) NextPageFunc {
+	return func(d *Document) *Document {
+		res, err := d.JSONPath(path)
+		if err != nil || len(res) == 0 || res[0] == nil {
+			return nil
+		}
+		cursor := fmt.Sprint(res[0])
+		if f, ok := res[0].(float64); ok {
+			cursor = strconv.FormatFloat(f, 'f', -1, 64)
+		}
+		if cursor == "" || cursor == "false" {
+			return nil
+		}
+		if param == "" {
+			return d.NewDoc(cursor)
+		}
+		u := *d.URL()
+		q := u.Query()
+		q.Set(param, cursor)
+		u.RawQuery = q.Encode()
+		return d.NewDoc(u.String())
+	}
+}
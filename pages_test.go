@@ -0,0 +1,95 @@
+package httpdoc
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestDocument_Pages(t *testing.T) {
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		switch r.URL.Path {
+		case "/html":
+			next := ""
+			if page < 3 {
+				next = fmt.Sprintf(`<link rel="next" href="?page=%d"><a class="next" href="?page=%d">next</a>`, page+1, page+1)
+			} else {
+				next = `<a class="next" href="?page=1">first</a>` // loop to the first page
+			}
+			fmt.Fprintf(w, `<html><head>%s</head><body>page %d</body></html>`, next, page)
+
+		case "/param":
+			if page > 4 {
+				w.Write([]byte("[]"))
+			} else {
+				fmt.Fprintf(w, `[%d]`, page)
+			}
+
+		case "/list":
+			if page < 3 {
+				fmt.Fprintf(w, `<html><body><ul><li>item %d</li></ul></body></html>`, page)
+			} else {
+				fmt.Fprintf(w, `<html><body><p>No results on page %d</p></body></html>`, page)
+			}
+
+		case "/broken":
+			if page > 1 {
+				http.Error(w, "oops", http.StatusInternalServerError)
+			} else {
+				w.Write([]byte(`<a rel="next" href="?page=2">next</a>`))
+			}
+
+		case "/cursor":
+			cursor, _ := strconv.Atoi(r.URL.Query().Get("cursor"))
+			if cursor < 2 {
+				fmt.Fprintf(w, `{"items":[%d],"next":%d}`, cursor, cursor+1)
+			} else {
+				fmt.Fprintf(w, `{"items":[%d],"next":null}`, cursor)
+			}
+		}
+	})
+
+	urls := func(url string, next any, maxPages int) (res []string) {
+		for page, err := range newDocument(srv.URL+url, NewClient(), nil).Pages(next, maxPages) {
+			assert(t, err == nil)
+			res = append(res, page.URL().RequestURI())
+		}
+		return
+	}
+	assert(t, fmt.Sprint(urls("/html?page=1", nil, 0)) == "[/html?page=1 /html?page=2 /html?page=3]")
+	assert(t, fmt.Sprint(urls("/html?page=1", "a.next", 0)) == "[/html?page=1 /html?page=2 /html?page=3]")
+	assert(t, fmt.Sprint(urls("/html?page=1", "a.next", 2)) == "[/html?page=1 /html?page=2]")
+	assert(t, fmt.Sprint(urls("/param", PageParam("page"), 0)) == "[/param /param?page=2 /param?page=3 /param?page=4]")
+	assert(t, fmt.Sprint(urls("/list", NonEmptyPages(PageParam("page"), "li"), 10)) == "[/list /list?page=2]")
+	assert(t, fmt.Sprint(urls("/cursor", JSONCursor("cursor", "$.next"), 0)) == "[/cursor /cursor?cursor=1 /cursor?cursor=2]")
+	assert(t, fmt.Sprint(urls("/html?page=1", func(d *Document) string { return "" }, 0)) == "[/html?page=1]")
+
+	// error of loading
+	var errs []error
+	for _, err := range newDocument(srv.URL+"/broken", NewClient(), nil).Pages(nil) {
+		errs = append(errs, err)
+	}
+	assert(t, len(errs) == 2 && errs[0] == nil && errs[1] != nil)
+
+	// iterator can be reused
+	pages := newDocument(srv.URL+"/html?page=1", NewClient(), nil).Pages(nil)
+	n := 0
+	for range pages {
+		n++
+	}
+	for range pages {
+		n++
+	}
+	assert(t, n == 6)
+
+	// break of loop
+	n = 0
+	for range newDocument(srv.URL+"/param", NewClient(), nil).Pages(PageParam("page")) {
+		if n++; n == 2 {
+			break
+		}
+	}
+	assert(t, n == 2)
+}
@@ -1,44 +1,291 @@
 package httpdoc
 
 import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/denisskin/gosync"
+	"golang.org/x/net/proxy"
 )
 
-func NewProxyClient(proxyAddr string) *http.Client {
-	c := NewClient()
-	c.Transport = NewProxyTransport(proxyAddr)
+// NewProxyClient creates http-client that sends all requests through proxy.
+func NewProxyClient(proxyAddr string, opts ...ClientOption) *http.Client {
+	c := NewClient(opts...)
+	c.Transport = NewProxyTransport(proxyAddr, opts...)
 	return c
 }
 
-func NewProxyTransport(proxyAddr string) *http.Transport {
+// NewProxyTransport creates transport for proxy-address "[scheme://][user:pass@]host:port"
+// (schemes: http, https, socks5, socks5h, socks4, socks4a)
+func NewProxyTransport(proxyAddr string, opts ...ClientOption) *http.Transport {
+	p, err := parseProxyURL(proxyAddr)
+	panicOnErr(err)
+
+	o := newClientOptions(opts)
+	tr := o.newTransport()
+	dialer := o.newDialer()
+
+	switch p.Scheme {
+	case "http", "https":
+		tr.Proxy = http.ProxyURL(p)
+		if auth := proxyAuthorization(p); auth != "" {
+			tr.ProxyConnectHeader = http.Header{"Proxy-Authorization": {auth}}
+		}
+
+	case "socks5", "socks5h":
+		var auth *proxy.Auth
+		if p.User != nil {
+			password, _ := p.User.Password()
+			auth = &proxy.Auth{User: p.User.Username(), Password: password}
+		}
+		d, err := proxy.SOCKS5("tcp", p.Host, auth, dialer)
+		panicOnErr(err)
+		dial := d.(proxy.ContextDialer).DialContext
+		if p.Scheme == "socks5" {
+			dial = resolveLocally(dial)
+		}
+		tr.Proxy = nil
+		tr.DialContext = dial
+
+	case "socks4", "socks4a":
+		tr.Proxy = nil
+		tr.DialContext = (&socks4Dialer{
+			proxyAddr: p.Host,
+			userID:    p.User.Username(),
+			remoteDNS: p.Scheme == "socks4a",
+			forward:   dialer,
+		}).DialContext
+
+	default:
+		panic(fmt.Errorf("httpdoc: unsupported proxy scheme %q", p.Scheme))
+	}
+	return tr
+}
+
+func parseProxyURL(proxyAddr string) (*url.URL, error) {
 	if !strings.Contains(proxyAddr, "//") {
 		proxyAddr = "//" + proxyAddr
 	}
 	p, err := url.Parse(proxyAddr)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 	if p.Scheme == "" {
 		p.Scheme = "http"
 	}
-	return &http.Transport{
-		Proxy: http.ProxyURL(p),
+	p.Scheme = strings.ToLower(p.Scheme)
+	if p.Port() == "" && strings.HasPrefix(p.Scheme, "socks") {
+		p.Host = net.JoinHostPort(p.Hostname(), "1080")
+	}
+	return p, nil
+}
+
+func proxyAuthorization(p *url.URL) string {
+	if p.User == nil {
+		return ""
 	}
+	password, _ := p.User.Password()
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(p.User.Username()+":"+password))
 }
 
-var proxyClientsCache = gosync.NewCache(1000)
+type dialContextFunc func(ctx context.Context, network, addr string) (net.Conn, error)
 
-func (d *Document) SetProxy(proxyAddr string) *Document {
-	if proxyAddr != "" {
-		d.Client, _ = proxyClientsCache.Get(proxyAddr).(*http.Client)
-		if d.Client == nil {
-			d.Client = NewProxyClient(proxyAddr)
-			proxyClientsCache.Set(proxyAddr, d.Client)
+// resolveLocally resolves host name before dialing, so proxy gets ip-address only
+func resolveLocally(dial dialContextFunc) dialContextFunc {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		if net.ParseIP(host) == nil {
+			ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+			if err != nil {
+				return nil, err
+			}
+			addr = net.JoinHostPort(ips[0].IP.String(), port)
+		}
+		return dial(ctx, network, addr)
+	}
+}
+
+type socks4Dialer struct {
+	proxyAddr string
+	userID    string
+	remoteDNS bool
+	forward   *net.Dialer
+}
+
+func (s *socks4Dialer) DialContext(ctx context.Context, network, addr string) (_ net.Conn, err error) {
+	host, sPort, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.ParseUint(sPort, 10, 16)
+	if err != nil {
+		return nil, err
+	}
+	ip := net.ParseIP(host).To4()
+	if ip == nil && !s.remoteDNS {
+		ips, err := net.DefaultResolver.LookupIP(ctx, "ip4", host)
+		if err != nil {
+			return nil, err
+		}
+		ip = ips[0].To4()
+	}
+	req := []byte{4, 1, byte(port >> 8), byte(port)}
+	if ip != nil {
+		req = append(req, ip...)
+	} else {
+		req = append(req, 0, 0, 0, 1) // SOCKS4a: ask proxy to resolve host name
+	}
+	req = append(append(req, s.userID...), 0)
+	if ip == nil {
+		req = append(append(req, host...), 0)
+	}
+
+	conn, err := s.forward.DialContext(ctx, "tcp", s.proxyAddr)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			conn.Close()
 		}
+	}()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+	if _, err = conn.Write(req); err != nil {
+		return nil, err
+	}
+	resp := make([]byte, 8)
+	if _, err = io.ReadFull(conn, resp); err != nil {
+		return nil, err
+	}
+	if resp[0] != 0 {
+		return nil, fmt.Errorf("httpdoc: invalid reply of socks4 proxy (version %d)", resp[0])
+	}
+	if resp[1] != 0x5a {
+		return nil, fmt.Errorf("httpdoc: socks4 proxy rejected connection (code %d)", resp[1])
+	}
+	return conn, nil
+}
+
+var DefaultProxyCheckURL = "https://www.google.com/generate_204"
+
+// CheckProxy sends test request through proxy and returns response time.
+// Uses DefaultProxyCheckURL if testURL is empty.
+func CheckProxy(proxyAddr, testURL string, opts ...ClientOption) (latency time.Duration, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("httpdoc.CheckProxy: %v", r)
+		}
+	}()
+	if testURL == "" {
+		testURL = DefaultProxyCheckURL
+	}
+	c := NewProxyClient(proxyAddr, opts...)
+	defer c.CloseIdleConnections()
+
+	start := time.Now()
+	resp, err := c.Get(testURL)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 400 {
+		return 0, errors.New("httpdoc.CheckProxy: " + resp.Status)
+	}
+	return time.Since(start), nil
+}
+
+var proxyTransportsCache = gosync.NewCache(1000)
+
+// proxyTransportKey is key of cache of proxy transports (transport settings of ClientOptions)
+type proxyTransportKey struct {
+	proxyAddr             string
+	dialTimeout           time.Duration
+	keepAlive             time.Duration
+	tlsHandshakeTimeout   time.Duration
+	responseHeaderTimeout time.Duration
+	idleConnTimeout       time.Duration
+	maxIdleConns          int
+	maxIdleConnsPerHost   int
+	http2                 bool
+	rootCAs               *x509.CertPool
+	certificates          string // hash of client certificates
+	insecureSkipVerify    bool
+	localAddr             string
+}
+
+func newProxyTransportKey(proxyAddr string, o ClientOptions) proxyTransportKey {
+	h := sha256.New()
+	for _, cert := range o.Certificates {
+		for _, der := range cert.Certificate {
+			h.Write(der)
+		}
+		h.Write([]byte{0})
+	}
+	return proxyTransportKey{
+		proxyAddr:             proxyAddr,
+		dialTimeout:           o.DialTimeout,
+		keepAlive:             o.KeepAlive,
+		tlsHandshakeTimeout:   o.TLSHandshakeTimeout,
+		responseHeaderTimeout: o.ResponseHeaderTimeout,
+		idleConnTimeout:       o.IdleConnTimeout,
+		maxIdleConns:          o.MaxIdleConns,
+		maxIdleConnsPerHost:   o.MaxIdleConnsPerHost,
+		http2:                 o.HTTP2,
+		rootCAs:               o.RootCAs,
+		certificates:          string(h.Sum(nil)),
+		insecureSkipVerify:    o.InsecureSkipVerify,
+		localAddr:             o.LocalAddr,
+	}
+}
+
+func cachedProxyTransport(proxyAddr string, opts []ClientOption) http.RoundTripper {
+	key := newProxyTransportKey(proxyAddr, newClientOptions(opts))
+	tr, _ := proxyTransportsCache.Get(key).(http.RoundTripper)
+	if tr == nil {
+		tr = NewProxyTransport(proxyAddr, opts...)
+		proxyTransportsCache.Set(key, tr)
+	}
+	return tr
+}
+
+// SetProxy makes document to send request through proxy.
+// The new client inherits cookie-jar, redirect policy, timeout and middlewares of the current document client.
+func (d *Document) SetProxy(proxyAddr string, opts ...ClientOption) *Document {
+	if proxyAddr != "" {
+		d.Client = newProxyClientFrom(d.Client, proxyAddr, opts)
 	}
 	return d
 }
+
+func newProxyClientFrom(c *http.Client, proxyAddr string, opts []ClientOption) *http.Client {
+	if c == nil {
+		c = DefaultClient
+	}
+	pc := *c
+	pc.Transport = cachedProxyTransport(proxyAddr, opts)
+	if mw := clientMiddlewares(c); mw != nil {
+		pc.Transport = &middlewareTransport{pc.Transport, mw}
+	}
+	if len(opts) > 0 {
+		pc.Timeout = newClientOptions(opts).Timeout
+	}
+	return &pc
+}
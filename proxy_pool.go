@@ -0,0 +1,276 @@
+package httpdoc
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"regexp"
+	"sync"
+	"time"
+)
+
+type ProxyRotation int
+
+const (
+	RotateRoundRobin ProxyRotation = iota // use next alive proxy for each request
+	RotateRandom                          // use random alive proxy for each request
+	StickyPerHost                         // use the same proxy for all requests to host
+	StickyPerSession                      // use the same proxy for all requests with the same cookie-jar
+)
+
+var ErrNoAliveProxies = errors.New("httpdoc: no alive proxies in pool")
+
+// ProxyPool rotates requests through list of proxies.
+// Proxies are marked as dead after connection errors or ban signals and are re-tested in background.
+type ProxyPool struct {
+	Rotation       ProxyRotation
+	MaxFails       int                  // number of consecutive connection errors to mark proxy as dead
+	RetestInterval time.Duration        // interval of re-testing of dead proxies
+	TestURL        string               // url for re-testing of dead proxies (DefaultProxyCheckURL by default)
+	IsBanned       func(*Document) bool // ban detector; IsBannedResponse by default
+
+	opts    []ClientOption
+	mx      sync.Mutex
+	proxies []*poolProxy
+	next    int
+	sticky  map[string]*poolProxy
+	closed  chan struct{}
+}
+
+type ProxyStats struct {
+	Addr      string
+	Alive     bool
+	Requests  int
+	Fails     int // connection errors
+	Bans      int
+	LastError error
+	LastUsed  time.Time
+	LastCheck time.Time
+	Latency   time.Duration // response time of last successful request or check
+}
+
+type poolProxy struct {
+	ProxyStats
+	seqFails int
+}
+
+func NewProxyPool(proxyAddrs []string, opts ...ClientOption) *ProxyPool {
+	p := &ProxyPool{
+		MaxFails:       3,
+		RetestInterval: time.Minute,
+		IsBanned:       IsBannedResponse,
+		opts:           opts,
+		sticky:         map[string]*poolProxy{},
+		closed:         make(chan struct{}),
+	}
+	for _, addr := range proxyAddrs {
+		p.Add(addr)
+	}
+	return p
+}
+
+var (
+	reCaptchaMarkers = regexp.MustCompile(`(?i:g-recaptcha|h-captcha|/captcha/)`)
+	reChallengePage  = regexp.MustCompile(`(?i:cf_chl_opt|/cdn-cgi/challenge-platform/|captcha-delivery\.com|<title>\s*just a moment\.\.\.\s*</title>)`)
+)
+
+// IsBannedResponse detects ban by status code (403, 429), by captcha on page with status 503
+// or by anti-bot challenge page (Cloudflare, DataDome)
+func IsBannedResponse(d *Document) bool {
+	if d.Response == nil {
+		return false
+	}
+	switch d.Response.StatusCode {
+	case 403, 429:
+		return true
+	case 503:
+		if reCaptchaMarkers.Match(d.Body) {
+			return true
+		}
+	}
+	return reChallengePage.Match(d.Body)
+}
+
+func (p *ProxyPool) Add(proxyAddr string) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	p.proxies = append(p.proxies, &poolProxy{ProxyStats: ProxyStats{Addr: proxyAddr, Alive: true}})
+}
+
+// Close stops background re-testing of dead proxies
+func (p *ProxyPool) Close() {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	select {
+	case <-p.closed:
+	default:
+		close(p.closed)
+	}
+}
+
+// Stats returns statistics of all proxies in pool
+func (p *ProxyPool) Stats() []ProxyStats {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	stats := make([]ProxyStats, len(p.proxies))
+	for i, pp := range p.proxies {
+		stats[i] = pp.ProxyStats
+	}
+	return stats
+}
+
+// AliveCount returns number of alive proxies
+func (p *ProxyPool) AliveCount() (n int) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	for _, pp := range p.proxies {
+		if pp.Alive {
+			n++
+		}
+	}
+	return
+}
+
+// Next returns address of the next proxy for sticky-key (host or session id)
+func (p *ProxyPool) Next(key string) (string, error) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	pp := p.pick(key)
+	if pp == nil {
+		return "", ErrNoAliveProxies
+	}
+	pp.Requests++
+	pp.LastUsed = time.Now()
+	return pp.Addr, nil
+}
+
+func (p *ProxyPool) pick(key string) *poolProxy {
+	sticky := p.Rotation == StickyPerHost || p.Rotation == StickyPerSession
+	if sticky {
+		if pp := p.sticky[key]; pp != nil && pp.Alive {
+			return pp
+		}
+	}
+	var alive []*poolProxy
+	for _, pp := range p.proxies {
+		if pp.Alive {
+			alive = append(alive, pp)
+		}
+	}
+	if len(alive) == 0 {
+		return nil
+	}
+	var pp *poolProxy
+	if p.Rotation == RotateRandom {
+		pp = alive[rand.Intn(len(alive))]
+	} else {
+		pp = alive[p.next%len(alive)]
+		p.next++
+	}
+	if sticky {
+		p.sticky[key] = pp
+	}
+	return pp
+}
+
+func (p *ProxyPool) stickyKey(d *Document) string {
+	switch p.Rotation {
+	case StickyPerHost:
+		return d.Request.URL.Host
+	case StickyPerSession:
+		if d.Client != nil && d.Client.Jar != nil {
+			return fmt.Sprintf("%p", d.Client.Jar)
+		}
+	}
+	return ""
+}
+
+func (p *ProxyPool) setProxy(d *Document) error {
+	addr, err := p.Next(p.stickyKey(d))
+	if err != nil {
+		return err
+	}
+	d.proxyAddr = addr
+	d.SetProxy(addr, p.opts...)
+	return nil
+}
+
+// report registers result of request through proxy
+func (p *ProxyPool) report(d *Document, reqErr error, latency time.Duration) {
+	banned := reqErr == nil && p.IsBanned != nil && p.IsBanned(d) // user-defined detector is called without lock
+
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	var pp *poolProxy
+	for _, v := range p.proxies {
+		if v.Addr == d.proxyAddr {
+			pp = v
+			break
+		}
+	}
+	if pp == nil || !pp.Alive {
+		return
+	}
+	switch {
+	case reqErr != nil:
+		pp.Fails++
+		pp.seqFails++
+		pp.LastError = reqErr
+		if pp.seqFails >= p.MaxFails {
+			p.markDead(pp)
+		}
+
+	case banned:
+		pp.Bans++
+		pp.LastError = fmt.Errorf("httpdoc: proxy is banned by %s (%s)", d.URL().Host, d.Response.Status)
+		p.markDead(pp)
+
+	default:
+		pp.seqFails = 0
+		pp.Latency = latency
+	}
+}
+
+func (p *ProxyPool) markDead(pp *poolProxy) {
+	pp.Alive = false
+	for key, v := range p.sticky {
+		if v == pp {
+			delete(p.sticky, key)
+		}
+	}
+	if p.RetestInterval > 0 {
+		go p.retest(pp)
+	}
+}
+
+func (p *ProxyPool) retest(pp *poolProxy) {
+	for {
+		select {
+		case <-p.closed:
+			return
+		case <-time.After(p.RetestInterval):
+		}
+		latency, err := CheckProxy(pp.Addr, p.TestURL, p.opts...)
+
+		p.mx.Lock()
+		pp.LastCheck = time.Now()
+		if err == nil {
+			pp.Alive = true
+			pp.seqFails = 0
+			pp.Latency = latency
+		} else {
+			pp.LastError = err
+		}
+		p.mx.Unlock()
+
+		if err == nil {
+			return
+		}
+	}
+}
+
+// SetProxyPool makes document (and all documents created from it by NewDoc) to use proxies from pool
+func (d *Document) SetProxyPool(pool *ProxyPool) *Document {
+	d.proxyPool = pool
+	return d
+}
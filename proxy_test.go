@@ -0,0 +1,118 @@
+package httpdoc
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestSOCKS4aProxy(t *testing.T) {
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<title>Hello, " + r.Host + "</title>"))
+	})
+
+	// minimal socks4a-server; connects any requested host to test http-server and replies with given version
+	requestedHost := make(chan string, 1)
+	socksServer := func(version byte) net.Listener {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		assert(t, err == nil)
+		t.Cleanup(func() { ln.Close() })
+		go func() {
+			for {
+				conn, err := ln.Accept()
+				if err != nil {
+					return
+				}
+				go func() {
+					defer conn.Close()
+					r := bufio.NewReader(conn)
+					io.ReadFull(r, make([]byte, 8))
+					r.ReadString(0) // user id
+					host, _ := r.ReadString(0)
+					requestedHost <- host
+					backend, err := net.Dial("tcp", srv.Listener.Addr().String())
+					if err != nil {
+						return
+					}
+					defer backend.Close()
+					conn.Write([]byte{version, 0x5a, 0, 0, 0, 0, 0, 0})
+					go io.Copy(backend, r)
+					io.Copy(conn, backend)
+				}()
+			}
+		}()
+		return ln
+	}
+
+	doc := NewDocument("http://example.test/").SetProxy("socks4a://" + socksServer(0).Addr().String())
+
+	assert(t, doc.Load() == nil)
+	assert(t, "example.test\x00" == <-requestedHost)
+	assert(t, "Hello, example.test" == doc.Title())
+
+	// peer which is not socks4-proxy
+	doc = NewDocument("http://example.test/").SetProxy("socks4a://" + socksServer(5).Addr().String())
+	assert(t, doc.Load() != nil)
+}
+
+func TestProxyPool(t *testing.T) {
+	bannedProxy := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	goodProxy := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<title>" + r.URL.String() + "</title>"))
+	})
+
+	pool := NewProxyPool([]string{bannedProxy.URL, goodProxy.URL})
+	pool.RetestInterval = 0
+	pool.IsBanned = func(d *Document) bool {
+		return pool.AliveCount() > 0 && IsBannedResponse(d) // detector may use pool
+	}
+	defer pool.Close()
+
+	doc := NewDocument("http://example.test/a").SetProxyPool(pool)
+	assert(t, doc.Load() != nil)
+
+	doc = NewDocument("http://example.test/b").SetProxyPool(pool)
+	assert(t, doc.Load() == nil)
+	assert(t, "http://example.test/b" == doc.Title())
+
+	stats := pool.Stats()
+	assert(t, !stats[0].Alive && stats[0].Bans == 1)
+	assert(t, stats[1].Alive && stats[1].Requests == 1)
+	assert(t, pool.AliveCount() == 1)
+}
+
+func TestIsBannedResponse(t *testing.T) {
+	for _, c := range []struct {
+		status int
+		body   string
+		banned bool
+	}{
+		{200, `<form><div class="g-recaptcha" data-sitekey="x"></div></form>`, false},
+		{503, `<form><div class="g-recaptcha" data-sitekey="x"></div></form>`, true},
+		{429, ``, true},
+		{200, `<html><head><title>Just a moment...</title></head><script>window._cf_chl_opt={}</script></html>`, true},
+		{200, `<p>ok</p>`, false},
+	} {
+		doc := NewDocument("https://example.com/").SetResponse(&http.Response{StatusCode: c.status}, []byte(c.body))
+		assert(t, IsBannedResponse(doc) == c.banned)
+	}
+}
+
+func TestCachedProxyTransport(t *testing.T) {
+	cert := tls.Certificate{Certificate: [][]byte{[]byte("cert")}, PrivateKey: "key"}
+	pool := x509.NewCertPool()
+	tr := cachedProxyTransport("http://127.0.0.1:1", []ClientOption{WithClientCertificates(cert), WithRootCAs(pool)})
+
+	// timeout of client doesn't affect transport
+	assert(t, tr == cachedProxyTransport("http://127.0.0.1:1", []ClientOption{WithClientCertificates(cert), WithRootCAs(pool), WithTimeout(time.Second)}))
+	assert(t, tr != cachedProxyTransport("http://127.0.0.1:1", []ClientOption{WithClientCertificates(cert), WithRootCAs(x509.NewCertPool())}))
+	assert(t, tr != cachedProxyTransport("http://127.0.0.1:1", []ClientOption{WithRootCAs(pool)}))
+	assert(t, tr != cachedProxyTransport("http://127.0.0.1:2", []ClientOption{WithClientCertificates(cert), WithRootCAs(pool)}))
+}
@@ -0,0 +1,520 @@
+package httpdoc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/andybalholm/cascadia"
+	"github.com/antchfx/htmlquery"
+	"github.com/antchfx/xpath"
+	"github.com/goldic/js"
+	"golang.org/x/net/html"
+	"gopkg.in/yaml.v3"
+)
+
+// Rules describes scraper as data (YAML or JSON): start url, fields of items, pagination and links to follow
+type Rules struct {
+	StartURL     string                `yaml:"start_url" json:"start_url,omitempty"`
+	Items        *FieldRule            `yaml:"items" json:"items,omitempty"`                 // elements of items; by default whole page is single item
+	Fields       map[string]*FieldRule `yaml:"fields" json:"fields,omitempty"`               // fields of items
+	Next         *FieldRule            `yaml:"next" json:"next,omitempty"`                   // url of next page (attribute href of element by default)
+	MaxPages     int                   `yaml:"max_pages" json:"max_pages,omitempty"`         // max number of pages (0 - unlimited)
+	Follow       []*FollowRule         `yaml:"follow" json:"follow,omitempty"`               // links to pages scraped by nested rules
+	IgnoreErrors bool                  `yaml:"ignore_errors" json:"ignore_errors,omitempty"` // skip pages which can't be loaded
+}
+
+// FieldRule describes how to extract value of field, elements of items or url of next page
+type FieldRule struct {
+	Selector string `yaml:"selector" json:"selector,omitempty"` // css-selector
+	XPath    string `yaml:"xpath" json:"xpath,omitempty"`       // xpath expression (nodes or value, e.g. "count(//li)")
+	Attr     string `yaml:"attr" json:"attr,omitempty"`         // name of attribute
+	HTML     bool   `yaml:"html" json:"html,omitempty"`         // inner html instead of text
+	Regexp   string `yaml:"regexp" json:"regexp,omitempty"`     // regular expression; without selector it matches html of item
+	Type     string `yaml:"type" json:"type,omitempty"`         // string (default), int, float, bool, url, time
+	All      bool   `yaml:"all" json:"all,omitempty"`           // list of values of all matched elements
+	Required bool   `yaml:"required" json:"required,omitempty"` // item without value of field is skipped
+}
+
+// FollowRule describes links to pages which are scraped by nested rules
+type FollowRule struct {
+	Selector string `yaml:"selector" json:"selector,omitempty"` // css-selector of links
+	XPath    string `yaml:"xpath" json:"xpath,omitempty"`       // xpath of links
+	Attr     string `yaml:"attr" json:"attr,omitempty"`         // attribute with url (default "href")
+	Match    string `yaml:"match" json:"match,omitempty"`       // regular expression which absolute urls must match
+	Limit    int    `yaml:"limit" json:"limit,omitempty"`       // max number of links per page (0 - unlimited)
+	Rules    *Rules `yaml:"rules" json:"rules,omitempty"`       // rules of followed pages
+}
+
+// compiledRules are rules with compiled selectors and expressions; scraping doesn't modify rules
+type compiledRules struct {
+	*Rules
+	items  *compiledField
+	fields map[string]*compiledField
+	next   *compiledField
+	follow []*compiledFollow
+}
+
+type compiledField struct {
+	*FieldRule
+	css cascadia.Selector
+	xp  *xpath.Expr
+	re  *regexp.Regexp
+}
+
+type compiledFollow struct {
+	*FollowRule
+	link  *compiledField
+	re    *regexp.Regexp
+	rules *compiledRules
+}
+
+var ruleTypes = []string{"", "string", "int", "float", "bool", "url", "time"}
+
+// LoadRules reads and validates rules from YAML or JSON file
+func LoadRules(filename string) (*Rules, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	r, err := ParseRules(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return r, nil
+}
+
+// ParseRules parses and validates rules in YAML or JSON format. Unknown keys are errors.
+func ParseRules(data []byte) (*Rules, error) {
+	r := &Rules{}
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(r); err != nil {
+			return nil, fmt.Errorf("httpdoc: invalid json of rules: %w", err)
+		}
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(r); err != nil && err != io.EOF {
+			return nil, fmt.Errorf("httpdoc: invalid yaml of rules: %w", err)
+		}
+	}
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// UnmarshalYAML decodes rule of field; scalar value is css-selector (e.g. `title: h1`)
+func (f *FieldRule) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		f.Selector = node.Value
+		return nil
+	}
+	if node.Kind == yaml.MappingNode { // decoding of node ignores KnownFields option
+		for i := 0; i < len(node.Content); i += 2 {
+			if key := node.Content[i]; !hasStructTag(f, "yaml", key.Value) {
+				return fmt.Errorf("line %d: unknown field %q of rule", key.Line, key.Value)
+			}
+		}
+	}
+	type fieldRule FieldRule
+	return node.Decode((*fieldRule)(f))
+}
+
+// UnmarshalJSON decodes rule of field; string value is css-selector (e.g. `"title": "h1"`)
+func (f *FieldRule) UnmarshalJSON(data []byte) error {
+	if s := ""; json.Unmarshal(data, &s) == nil {
+		f.Selector = s
+		return nil
+	}
+	type fieldRule FieldRule
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode((*fieldRule)(f))
+}
+
+func hasStructTag(v any, tagName, name string) bool {
+	for _, f := range reflect.VisibleFields(reflect.TypeOf(v).Elem()) {
+		if tag, _, _ := strings.Cut(f.Tag.Get(tagName), ","); tag == name {
+			return true
+		}
+	}
+	return false
+}
+
+// RulesError is error of validation of rules. It contains all problems found in rules.
+type RulesError struct {
+	Problems []string // problems with paths of rules, e.g. `fields.price.type: unknown type "money"`
+}
+
+func (e *RulesError) Error() string {
+	return "httpdoc: invalid rules: " + strings.Join(e.Problems, "; ")
+}
+
+func (e *RulesError) add(path, format string, args ...any) {
+	e.Problems = append(e.Problems, path+": "+fmt.Sprintf(format, args...))
+}
+
+// Validate checks rules: start url, selectors, xpath expressions and regular expressions.
+// Returned error is *RulesError.
+func (r *Rules) Validate() error {
+	e := &RulesError{}
+	if u, err := url.Parse(r.StartURL); r.StartURL == "" {
+		e.add("start_url", "start url is required")
+	} else if err != nil || u.Host == "" || u.Scheme != "http" && u.Scheme != "https" {
+		e.add("start_url", "invalid absolute http-url %q", r.StartURL)
+	}
+	r.compile("", e)
+	return e.err()
+}
+
+// compiled returns compiled rules which are applied to given document (start url is not required)
+func (r *Rules) compiled() (*compiledRules, error) {
+	e := &RulesError{}
+	c := r.compile("", e)
+	return c, e.err()
+}
+
+func (e *RulesError) err() error {
+	if len(e.Problems) > 0 {
+		return e
+	}
+	return nil
+}
+
+func (r *Rules) compile(path string, e *RulesError) *compiledRules {
+	c := &compiledRules{Rules: r, fields: map[string]*compiledField{}}
+	if len(r.Fields) == 0 && len(r.Follow) == 0 {
+		e.add(path+"fields", "fields or follow-rules are required")
+	}
+	if r.Items != nil {
+		if c.items = r.Items.compile(path+"items", e); r.Items.Selector == "" && r.Items.XPath == "" {
+			e.add(path+"items", "selector or xpath is required")
+		}
+	}
+	if r.Next != nil {
+		next := *r.Next
+		if next.Attr == "" && !next.HTML && next.Regexp == "" && next.Selector != "" {
+			next.Attr = "href"
+		}
+		c.next = next.compile(path+"next", e)
+	}
+	if r.MaxPages < 0 {
+		e.add(path+"max_pages", "negative number of pages")
+	}
+	names := make([]string, 0, len(r.Fields))
+	for name := range r.Fields {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		if f := r.Fields[name]; f == nil {
+			e.add(path+"fields."+name, "empty rule")
+		} else {
+			c.fields[name] = f.compile(path+"fields."+name, e)
+		}
+	}
+	for i, f := range r.Follow {
+		fPath := fmt.Sprintf("%sfollow[%d]", path, i)
+		if f == nil {
+			e.add(fPath, "empty rule")
+			continue
+		}
+		link := &FieldRule{Selector: f.Selector, XPath: f.XPath, Attr: f.Attr, All: true}
+		if link.Attr == "" {
+			link.Attr = "href"
+		}
+		cf := &compiledFollow{FollowRule: f, link: link.compile(fPath, e)}
+		c.follow = append(c.follow, cf)
+		if f.Match != "" {
+			var err error
+			if cf.re, err = regexp.Compile(f.Match); err != nil {
+				e.add(fPath+".match", "invalid regexp: %v", err)
+			}
+		}
+		if f.Limit < 0 {
+			e.add(fPath+".limit", "negative limit")
+		}
+		if f.Rules == nil {
+			e.add(fPath+".rules", "rules of followed pages are required")
+		} else if f.Rules.StartURL != "" {
+			e.add(fPath+".rules.start_url", "start url of followed pages is not allowed")
+		} else {
+			cf.rules = f.Rules.compile(fPath+".rules.", e)
+		}
+	}
+	return c
+}
+
+func (f *FieldRule) compile(path string, e *RulesError) *compiledField {
+	c := &compiledField{FieldRule: f}
+	var err error
+	if f.Selector == "" && f.XPath == "" && f.Regexp == "" {
+		e.add(path, "selector, xpath or regexp is required")
+	}
+	if f.Selector != "" && f.XPath != "" {
+		e.add(path, "both selector and xpath are set")
+	}
+	if f.Attr != "" && f.HTML {
+		e.add(path, "both attr and html are set")
+	}
+	if f.Selector != "" {
+		if c.css, err = cascadia.Compile(f.Selector); err != nil {
+			e.add(path+".selector", "invalid css-selector %q: %v", f.Selector, err)
+		}
+	}
+	if f.XPath != "" {
+		if c.xp, err = xpath.Compile(f.XPath); err != nil {
+			e.add(path+".xpath", "invalid xpath %q: %v", f.XPath, err)
+		}
+	}
+	if f.Regexp != "" {
+		if c.re, err = regexp.Compile(f.Regexp); err != nil {
+			e.add(path+".regexp", "invalid regexp: %v", err)
+		}
+	}
+	if !slices.Contains(ruleTypes, f.Type) {
+		e.add(path+".type", "unknown type %q (expected one of: %s)", f.Type, strings.Join(ruleTypes[1:], ", "))
+	}
+	return c
+}
+
+// Run scrapes pages starting from start url and writes items to w as JSON lines
+func (r *Rules) Run(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return r.Scrape(NewDocument(r.StartURL), func(item js.Object) error {
+		return enc.Encode(item)
+	})
+}
+
+// Scrape applies rules to document, to its next pages and to followed links; fn is called for every item.
+// Every url is loaded once.
+func (r *Rules) Scrape(doc *Document, fn func(item js.Object) error) error {
+	c, err := r.compiled()
+	if err != nil {
+		return err
+	}
+	return c.scrape(doc, map[string]bool{}, fn)
+}
+
+func (r *compiledRules) scrape(doc *Document, visited map[string]bool, fn func(item js.Object) error) error {
+	for page := 0; doc != nil && (r.MaxPages == 0 || page < r.MaxPages); page++ {
+		key := visitedKey(doc.URL())
+		if visited[key] {
+			break
+		}
+		visited[key] = true
+		if err := doc.Load(); err != nil {
+			if r.IgnoreErrors {
+				return nil
+			}
+			return fmt.Errorf("httpdoc: scrape %s: %w", doc.URL(), err)
+		}
+		visited[visitedKey(doc.URL())] = true // url after redirects
+		root := doc.htmlRoot()
+
+		if len(r.Fields) > 0 {
+			items, err := r.extract(doc, root)
+			if err != nil {
+				return err
+			}
+			for _, item := range items {
+				if err := fn(item); err != nil {
+					return err
+				}
+			}
+		}
+		for _, f := range r.follow {
+			links, _ := f.link.values(doc, root)
+			n := 0
+			for _, link := range links {
+				href := doc.AbsURL(link.(string))
+				if u, err := url.Parse(href); err != nil || u.Scheme != "http" && u.Scheme != "https" ||
+					f.re != nil && !f.re.MatchString(href) || visited[visitedKey(u)] {
+					continue
+				}
+				if n++; f.Limit > 0 && n > f.Limit {
+					break
+				}
+				if err := f.rules.scrape(doc.NewDoc(href), visited, fn); err != nil {
+					return err
+				}
+			}
+		}
+		var next *Document
+		if r.next != nil {
+			if vv, _ := r.next.values(doc, root); len(vv) > 0 {
+				if href, _ := vv[0].(string); href != "" {
+					next = doc.NewDoc(href)
+				}
+			}
+		}
+		doc = next
+	}
+	return nil
+}
+
+// Extract extracts items from document by rules (without pagination and following of links)
+func (r *Rules) Extract(doc *Document) ([]js.Object, error) {
+	c, err := r.compiled()
+	if err != nil {
+		return nil, err
+	}
+	if err := doc.Load(); err != nil {
+		return nil, err
+	}
+	return c.extract(doc, doc.htmlRoot())
+}
+
+func (r *compiledRules) extract(doc *Document, root *html.Node) (items []js.Object, err error) {
+	scopes := []*html.Node{root}
+	if r.items != nil {
+		scopes = r.items.nodes(doc, root)
+	}
+	for _, scope := range scopes {
+		item := js.Object{}
+		for name, f := range r.fields {
+			vv, err := f.values(doc, scope)
+			if err != nil {
+				return nil, fmt.Errorf("httpdoc: scrape %s: field %q: %w", doc.URL(), name, err)
+			}
+			if len(vv) == 0 && f.Required {
+				item = nil
+				break
+			}
+			if f.All {
+				item[name] = append([]any{}, vv...)
+			} else if len(vv) > 0 {
+				item[name] = vv[0]
+			}
+		}
+		if item != nil {
+			items = append(items, item)
+		}
+	}
+	return
+}
+
+// nodes returns elements matched by selector or xpath; without selector and xpath it returns scope
+func (f *compiledField) nodes(doc *Document, scope *html.Node) []*html.Node {
+	switch {
+	case f.css != nil:
+		return cascadia.QueryAll(scope, f.css)
+	case f.xp != nil:
+		if iter, ok := f.xp.Evaluate(htmlquery.CreateXPathNavigator(scope)).(*xpath.NodeIterator); ok {
+			var nodes []*html.Node
+			for iter.MoveNext() {
+				nodes = append(nodes, iter.Current().(*htmlquery.NodeNavigator).Current())
+			}
+			return nodes
+		}
+		return nil
+	}
+	return []*html.Node{scope}
+}
+
+// values returns converted values of field (only first value if field is not marked as "all")
+func (f *compiledField) values(doc *Document, scope *html.Node) (values []any, err error) {
+	var texts []string
+	if f.xp != nil {
+		switch v := f.xp.Evaluate(htmlquery.CreateXPathNavigator(scope)).(type) {
+		case *xpath.NodeIterator:
+			for v.MoveNext() {
+				nav := v.Current().(*htmlquery.NodeNavigator)
+				if nav.NodeType() == xpath.AttributeNode || nav.NodeType() == xpath.TextNode {
+					texts = append(texts, nav.Value())
+				} else {
+					texts = append(texts, f.nodeText(nav.Current()))
+				}
+			}
+		default: // number, string or bool
+			texts = append(texts, fmt.Sprint(v))
+		}
+	} else if f.css != nil {
+		for _, n := range cascadia.QueryAll(scope, f.css) {
+			if f.Attr == "" || hasHTMLAttr(n, f.Attr) {
+				texts = append(texts, f.nodeText(n))
+			}
+		}
+	} else if scope.Type == html.DocumentNode { // regexp of whole document matches its source
+		texts = append(texts, doc.ContentStr())
+	} else {
+		var sb strings.Builder
+		html.Render(&sb, scope)
+		texts = append(texts, sb.String())
+	}
+
+	for _, s := range texts {
+		var matched []string
+		if f.re == nil {
+			matched = []string{s}
+		} else {
+			for _, ss := range f.re.FindAllStringSubmatch(s, -1) {
+				matched = append(matched, ss[min(1, len(ss)-1)])
+				if !f.All {
+					break
+				}
+			}
+		}
+		for _, s := range matched {
+			if s = strings.TrimSpace(s); s == "" {
+				continue
+			}
+			v, err := f.convert(doc, s)
+			if err != nil {
+				return nil, err
+			}
+			if values = append(values, v); !f.All {
+				return values, nil
+			}
+		}
+	}
+	return
+}
+
+func (f *FieldRule) nodeText(n *html.Node) string {
+	switch {
+	case f.Attr != "":
+		return htmlAttr(n, f.Attr)
+	case f.HTML:
+		var sb strings.Builder
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			html.Render(&sb, c)
+		}
+		return sb.String()
+	}
+	return htmlCellText(n)
+}
+
+func (f *FieldRule) convert(doc *Document, s string) (any, error) {
+	var v any
+	switch f.Type {
+	case "int":
+		v = new(int64)
+	case "float":
+		v = new(float64)
+	case "bool":
+		v = new(bool)
+	case "time":
+		v = new(time.Time)
+	case "url":
+		return doc.AbsURL(s), nil
+	default:
+		return s, nil
+	}
+	if err := setValue(reflect.ValueOf(v).Elem(), s); err != nil {
+		return nil, err
+	}
+	return reflect.ValueOf(v).Elem().Interface(), nil
+}
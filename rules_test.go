@@ -0,0 +1,139 @@
+package httpdoc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func testShopServer(t *testing.T) *httptest.Server {
+	return newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/catalog":
+			page := r.URL.Query().Get("page")
+			next := ""
+			if page != "2" {
+				next = `<a rel="next" href="?page=2">next</a>`
+			}
+			fmt.Fprintf(w, `<html><body><ul class="products">
+				<li data-sku="A%[1]s"><h3><a href="/p/a%[1]s">Item A%[1]s</a></h3><span class="price">$1,200.50</span><i class="tag">new</i><i class="tag">hot</i></li>
+				<li data-sku="B%[1]s"><h3><a href="/p/b%[1]s">Item B%[1]s</a></h3></li>
+			</ul>%[2]s</body></html>`, page, next)
+
+		default:
+			fmt.Fprintf(w, `<html><body><h1>%s</h1><p class="desc">Description of <b>item</b></p>
+				<script>var stock = 42;</script></body></html>`, r.URL.Path)
+		}
+	})
+}
+
+func TestRules_Run(t *testing.T) {
+	srv := testShopServer(t)
+
+	rules, err := ParseRules([]byte(`
+start_url: ` + srv.URL + `/catalog
+items: ul.products > li
+fields:
+  name: h3
+  url: {selector: h3 a, attr: href, type: url}
+  price: {selector: .price, type: float, required: true}
+  sku: {xpath: "@data-sku"}
+  tags: {selector: .tag, all: true}
+next: a[rel=next]
+max_pages: 5
+follow:
+  - selector: h3 a
+    match: /p/b
+    rules:
+      fields:
+        title: {xpath: "//h1"}
+        description: {selector: .desc, html: true}
+        stock: {regexp: 'stock = (\d+)', type: int}
+`))
+	assert(t, err == nil)
+
+	var buf bytes.Buffer
+	err = rules.Run(&buf)
+	assert(t, err == nil)
+
+	assert(t, buf.String() == `{"name":"Item A","price":1200.5,"sku":"A","tags":["new","hot"],"url":"`+srv.URL+`/p/a"}
+{"description":"Description of <b>item</b>","stock":42,"title":"/p/b"}
+{"name":"Item A2","price":1200.5,"sku":"A2","tags":["new","hot"],"url":"`+srv.URL+`/p/a2"}
+{"description":"Description of <b>item</b>","stock":42,"title":"/p/b2"}
+`)
+}
+
+func TestRules_Extract(t *testing.T) {
+	srv := testShopServer(t)
+
+	rules, err := ParseRules([]byte(`{
+		"start_url": "` + srv.URL + `/catalog",
+		"fields": {
+			"count": {"xpath": "count(//li)", "type": "int"},
+			"first": "li h3"
+		}
+	}`))
+	assert(t, err == nil)
+
+	items, err := rules.Extract(NewDocument(rules.StartURL))
+	assert(t, err == nil)
+	assert(t, len(items) == 1 && items[0]["count"] == int64(2) && items[0]["first"] == "Item A")
+
+	// rules are not modified by scraping and can be used concurrently
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			items, err := rules.Extract(NewDocument(rules.StartURL))
+			assert(t, err == nil && len(items) == 1)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestParseRules_errors(t *testing.T) {
+	_, err := ParseRules([]byte(`
+start_url: /catalog
+items: {regexp: "x"}
+fields:
+  price: {selector: "li[", type: money}
+  name: {xpath: "//h1[", regexp: "(", atr: "x"}
+follow:
+  - {selector: a}
+`))
+	assert(t, err != nil && err.Error() == `httpdoc: invalid yaml of rules: line 6: unknown field "atr" of rule`)
+
+	_, err = ParseRules([]byte(`
+start_url: /catalog
+items: {regexp: "x"}
+fields:
+  price: {selector: "li[", type: money}
+  name: {xpath: "//h1[", regexp: "("}
+follow:
+  - {selector: a}
+`))
+	var rulesErr *RulesError
+	assert(t, errors.As(err, &rulesErr))
+	problems := strings.Join(rulesErr.Problems, "\n")
+	for _, s := range []string{
+		`start_url: invalid absolute http-url "/catalog"`,
+		`items: selector or xpath is required`,
+		`fields.name.xpath: invalid xpath "//h1["`,
+		`fields.name.regexp: invalid regexp: error parsing regexp`,
+		`fields.price.selector: invalid css-selector "li["`,
+		`fields.price.type: unknown type "money" (expected one of: string, int, float, bool, url, time)`,
+		`follow[0].rules: rules of followed pages are required`,
+	} {
+		assert(t, strings.Contains(problems, s))
+	}
+	assert(t, len(rulesErr.Problems) == 7)
+
+	_, err = ParseRules([]byte(`{"start_url": "http://example.com/", "fields": {"x": "h1"}, "pages": 2}`))
+	assert(t, err != nil && err.Error() == `httpdoc: invalid json of rules: json: unknown field "pages"`)
+}
@@ -0,0 +1,434 @@
+package httpdoc
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/goldic/js"
+)
+
+var (
+	reScriptAttrSelector = regexp.MustCompile(`^(?i:script)?\[([a-zA-Z0-9\-_:]+)(?:=["']?([^"'\]]*)["']?)?\]$`)
+	reJSONNumber         = regexp.MustCompile(`^-?(?:0|[1-9][0-9]*)(?:\.[0-9]+)?(?:[eE][+-]?[0-9]+)?$`)
+)
+
+// ScriptJSON decodes to v json-data of script found by id ("__NEXT_DATA__"), attribute selector (`[type="application/ld+json"]`)
+// or name of js-variable ("window.__INITIAL_STATE__"). Js-literals are converted to json (see JSToJSON).
+func (d *Document) ScriptJSON(name string, v any) error {
+	data, err := d.scriptJSON(name)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// ScriptJSObject finds json-data embedded in scripts of html-document (see ScriptJSON)
+func (d *Document) ScriptJSObject(name string) (res js.Object, err error) {
+	err = d.ScriptJSON(name, &res)
+	return
+}
+
+func (d *Document) scriptJSON(name string) ([]byte, error) {
+	if err := d.Load(); err != nil {
+		return nil, err
+	}
+	scripts := d.Scripts()
+
+	// script-tag by id or attributes
+	var tags HTMLElements
+	if id, ok := strings.CutPrefix(name, "#"); ok {
+		tags = scripts.FilterByAttrValue("id", id)
+	} else if ss := reScriptAttrSelector.FindStringSubmatch(name); ss != nil {
+		if attr := strings.ToLower(ss[1]); strings.Contains(name, "=") {
+			tags = scripts.FilterByAttrValue(attr, ss[2])
+		} else {
+			tags = scripts.FilterByAttr(attr)
+		}
+	} else {
+		tags = scripts.FilterByAttrValue("id", name)
+	}
+	for _, tag := range tags {
+		if src := strings.TrimSpace(tag.InnerHTML); src != "" {
+			return JSToJSON(trimHTMLComment(src))
+		}
+	}
+
+	// assignment to js-variable or property
+	name = strings.TrimPrefix(name, "#")
+	for _, prefix := range []string{"window.", "self.", "globalThis."} {
+		name = strings.TrimPrefix(name, prefix)
+	}
+	qName := regexp.QuoteMeta(name)
+	reAssign := regexp.MustCompile(`(?:^|[^\w$.])(?:(?:window|self|globalThis)\s*(?:\.\s*` + qName + `|\[\s*["']` + qName + `["']\s*\])|` + qName + `)\s*=\s*`)
+	reProp := regexp.MustCompile(`(?:^|[^\w$.])(?:["']` + qName + `["']|` + qName + `)\s*:\s*`)
+	for _, re := range []*regexp.Regexp{reAssign, reProp} {
+		for _, script := range scripts {
+			src := script.InnerHTML
+			for _, loc := range re.FindAllStringIndex(src, -1) {
+				if loc[1] < len(src) && src[loc[1]] == '=' { // comparison "=="
+					continue
+				}
+				if data, err := jsValueToJSON(src[loc[1]:]); err == nil {
+					return data, nil
+				}
+			}
+		}
+	}
+	return nil, fmt.Errorf("httpdoc: script json %q not found", name)
+}
+
+func trimHTMLComment(s string) string {
+	s = strings.TrimSpace(strings.TrimPrefix(s, "<!--"))
+	return strings.TrimSpace(strings.TrimSuffix(s, "-->"))
+}
+
+// jsValueToJSON converts js-value at the beginning of src (literal or JSON.parse("...") call) to json
+func jsValueToJSON(src string) ([]byte, error) {
+	c := &jsConverter{s: src}
+	c.skipSpaces()
+	if !c.consume("JSON.parse(") {
+		return c.convert()
+	}
+	c.skipSpaces()
+	if c.eof() || !strings.ContainsRune(`"'`+"`", rune(c.s[c.pos])) {
+		return nil, c.errorf("string expected")
+	}
+	str, err := c.readString()
+	if err != nil {
+		return nil, err
+	}
+	if c.skipSpaces(); !c.consume(")") {
+		return nil, c.errorf(") expected")
+	}
+	return JSToJSON(str)
+}
+
+// JSToJSON converts js-literal to json (unquoted keys, single quotes, trailing commas, comments, undefined, !0, etc)
+func JSToJSON(src string) ([]byte, error) {
+	if json.Valid([]byte(src)) {
+		return []byte(src), nil
+	}
+	c := &jsConverter{s: src}
+	data, err := c.convert()
+	if err != nil {
+		return nil, err
+	}
+	c.skipSpaces()
+	c.consume(";")
+	if c.skipSpaces(); !c.eof() {
+		return nil, c.errorf("unexpected symbol")
+	}
+	return data, nil
+}
+
+type jsConverter struct {
+	s   string
+	pos int
+	out []byte
+}
+
+func (c *jsConverter) errorf(msg string) error {
+	return fmt.Errorf("httpdoc: invalid js-literal at position %d: %s", c.pos, msg)
+}
+
+func (c *jsConverter) eof() bool {
+	return c.pos >= len(c.s)
+}
+
+func (c *jsConverter) consume(prefix string) bool {
+	if strings.HasPrefix(c.s[c.pos:], prefix) {
+		c.pos += len(prefix)
+		return true
+	}
+	return false
+}
+
+// skipSpaces skips white spaces and comments
+func (c *jsConverter) skipSpaces() {
+	for !c.eof() {
+		switch ch := c.s[c.pos]; {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r' || ch == '\f' || ch == '\v':
+			c.pos++
+		case c.consume("//"):
+			if i := strings.IndexByte(c.s[c.pos:], '\n'); i >= 0 {
+				c.pos += i
+			} else {
+				c.pos = len(c.s)
+			}
+		case c.consume("/*"):
+			if i := strings.Index(c.s[c.pos:], "*/"); i >= 0 {
+				c.pos += i + 2
+			} else {
+				c.pos = len(c.s)
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (c *jsConverter) convert() ([]byte, error) {
+	if err := c.value(); err != nil {
+		return nil, err
+	}
+	return c.out, nil
+}
+
+func (c *jsConverter) value() error {
+	c.skipSpaces()
+	if c.eof() {
+		return c.errorf("unexpected end")
+	}
+	switch ch := c.s[c.pos]; {
+	case ch == '{':
+		return c.object()
+
+	case ch == '[':
+		return c.array()
+
+	case ch == '"' || ch == '\'' || ch == '`':
+		s, err := c.readString()
+		if err != nil {
+			return err
+		}
+		c.writeString(s)
+
+	case ch == '-' || ch == '+' || ch == '.' || ch >= '0' && ch <= '9':
+		return c.number()
+
+	case c.consume("!0"):
+		c.out = append(c.out, "true"...)
+
+	case c.consume("!1"):
+		c.out = append(c.out, "false"...)
+
+	case isJSIdentChar(ch):
+		switch ident := c.readIdent(); ident {
+		case "true", "false", "null":
+			c.out = append(c.out, ident...)
+		case "undefined", "NaN", "Infinity":
+			c.out = append(c.out, "null"...)
+		default:
+			return c.errorf("unsupported expression " + ident)
+		}
+
+	default:
+		return c.errorf("unexpected symbol")
+	}
+	return nil
+}
+
+func (c *jsConverter) object() error {
+	c.pos++ // {
+	c.out = append(c.out, '{')
+	for n := 0; ; n++ {
+		c.skipSpaces()
+		if c.consume("}") {
+			c.out = append(c.out, '}')
+			return nil
+		}
+		if n > 0 {
+			if !c.consume(",") {
+				return c.errorf(", or } expected")
+			}
+			if c.skipSpaces(); c.consume("}") { // trailing comma
+				c.out = append(c.out, '}')
+				return nil
+			}
+			c.out = append(c.out, ',')
+		}
+		if c.eof() {
+			return c.errorf("unexpected end")
+		}
+		// key
+		var key string
+		switch ch := c.s[c.pos]; {
+		case ch == '"' || ch == '\'' || ch == '`':
+			s, err := c.readString()
+			if err != nil {
+				return err
+			}
+			key = s
+		case isJSIdentChar(ch):
+			key = c.readIdent()
+		default:
+			return c.errorf("property name expected")
+		}
+		c.writeString(key)
+		if c.skipSpaces(); !c.consume(":") {
+			return c.errorf(": expected")
+		}
+		c.out = append(c.out, ':')
+		if err := c.value(); err != nil {
+			return err
+		}
+	}
+}
+
+func (c *jsConverter) array() error {
+	c.pos++ // [
+	c.out = append(c.out, '[')
+	for n := 0; ; n++ {
+		c.skipSpaces()
+		if c.consume("]") {
+			c.out = append(c.out, ']')
+			return nil
+		}
+		if n > 0 {
+			if !c.consume(",") {
+				return c.errorf(", or ] expected")
+			}
+			if c.skipSpaces(); c.consume("]") { // trailing comma
+				c.out = append(c.out, ']')
+				return nil
+			}
+			c.out = append(c.out, ',')
+		}
+		if err := c.value(); err != nil {
+			return err
+		}
+	}
+}
+
+func (c *jsConverter) number() error {
+	start := c.pos
+	if ch := c.s[c.pos]; ch == '-' || ch == '+' {
+		c.pos++
+	}
+	for !c.eof() {
+		ch := c.s[c.pos]
+		if ch == '.' || ch == '_' || isJSIdentChar(ch) {
+			c.pos++
+		} else if (ch == '+' || ch == '-') && (c.s[c.pos-1] == 'e' || c.s[c.pos-1] == 'E') {
+			c.pos++
+		} else {
+			break
+		}
+	}
+	num := strings.TrimPrefix(strings.ReplaceAll(c.s[start:c.pos], "_", ""), "+")
+	f, err := strconv.ParseFloat(num, 64)
+	isJSONNumber := reJSONNumber.MatchString(num)
+	switch i, errInt := strconv.ParseInt(num, 0, 64); {
+	case num == "Infinity" || num == "-Infinity" || isJSONNumber && math.IsInf(f, 0): // 1e999 is Infinity in js
+		c.out = append(c.out, "null"...)
+	case isJSONNumber:
+		c.out = append(c.out, num...)
+	case errInt == nil:
+		c.out = strconv.AppendInt(c.out, i, 10)
+	case err == nil && !math.IsInf(f, 0) && !math.IsNaN(f):
+		c.out = strconv.AppendFloat(c.out, f, 'g', -1, 64)
+	default:
+		c.pos = start
+		return c.errorf("invalid number")
+	}
+	return nil
+}
+
+func isJSIdentChar(ch byte) bool {
+	return ch == '_' || ch == '$' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9' || ch >= utf8.RuneSelf
+}
+
+func (c *jsConverter) readIdent() string {
+	start := c.pos
+	for !c.eof() && isJSIdentChar(c.s[c.pos]) {
+		c.pos++
+	}
+	return c.s[start:c.pos]
+}
+
+func (c *jsConverter) writeString(s string) {
+	data, _ := json.Marshal(s)
+	c.out = append(c.out, data...)
+}
+
+// readString reads and unescapes js-string in quotes '...', "..." or `...`
+func (c *jsConverter) readString() (string, error) {
+	quote := c.s[c.pos]
+	c.pos++
+	var buf []uint16 // pending utf-16 units of \u-escapes
+	var sb strings.Builder
+	flush := func() {
+		if len(buf) > 0 {
+			sb.WriteString(string(utf16.Decode(buf)))
+			buf = buf[:0]
+		}
+	}
+	for !c.eof() {
+		ch := c.s[c.pos]
+		switch {
+		case ch == quote:
+			c.pos++
+			flush()
+			return sb.String(), nil
+
+		case ch == '\n' && quote != '`':
+			return "", c.errorf("unterminated string")
+
+		case ch == '$' && quote == '`' && c.pos+1 < len(c.s) && c.s[c.pos+1] == '{':
+			return "", c.errorf("template expressions are not supported")
+
+		case ch == '\\' && c.pos+1 < len(c.s):
+			c.pos++
+			esc := c.s[c.pos]
+			c.pos++
+			if esc == 'u' || esc == 'x' {
+				hex := ""
+				switch {
+				case esc == 'x' && c.pos+2 <= len(c.s):
+					hex, c.pos = c.s[c.pos:c.pos+2], c.pos+2
+				case esc == 'u' && c.consume("{"):
+					if i := strings.IndexByte(c.s[c.pos:], '}'); i >= 0 {
+						hex, c.pos = c.s[c.pos:c.pos+i], c.pos+i+1
+					}
+				case esc == 'u' && c.pos+4 <= len(c.s):
+					hex, c.pos = c.s[c.pos:c.pos+4], c.pos+4
+				}
+				code, err := strconv.ParseUint(hex, 16, 32)
+				if err != nil {
+					return "", c.errorf("invalid escape sequence")
+				}
+				if code <= 0xffff {
+					buf = append(buf, uint16(code))
+				} else {
+					flush()
+					sb.WriteRune(rune(code))
+				}
+				continue
+			}
+			flush()
+			switch esc {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			case 'r':
+				sb.WriteByte('\r')
+			case 'b':
+				sb.WriteByte('\b')
+			case 'f':
+				sb.WriteByte('\f')
+			case 'v':
+				sb.WriteByte('\v')
+			case '0':
+				sb.WriteByte(0)
+			case '\r':
+				c.consume("\n") // line continuation
+			case '\n':
+			default:
+				sb.WriteByte(esc)
+			}
+
+		default:
+			flush()
+			sb.WriteByte(ch)
+			c.pos++
+		}
+	}
+	return "", c.errorf("unterminated string")
+}
@@ -0,0 +1,67 @@
+package httpdoc
+
+import "testing"
+
+func TestJSToJSON(t *testing.T) {
+	for src, expected := range map[string]string{
+		`{"a":1}`: `{"a":1}`,
+		`{a: 1, 'b': 'it\'s', c: [1, 2, ], /* comment */ d: !0, e: undefined, f: 0x10, g: .5,}`: `{"a":1,"b":"it's","c":[1,2],"d":true,"e":null,"f":16,"g":0.5}`,
+		`['A\x42😀', "\"q\"", ` + "`tpl`" + `]; // end`:                                          `["AB😀","\"q\"","tpl"]`,
+		`{id:"100001234567890",name:"John",$x:-1e3}`:                                            `{"id":"100001234567890","name":"John","$x":-1e3}`,
+		`{a: Infinity, b: -Infinity, c: NaN, d: 1e999}`:                                         `{"a":null,"b":null,"c":null,"d":null}`,
+	} {
+		data, err := JSToJSON(src)
+		assert(t, err == nil)
+		assert(t, string(data) == expected)
+	}
+	for _, src := range []string{`{a: foo()}`, `{a: 1`, `[1 2]`, "`${x}`", `{a: -inf}`} {
+		_, err := JSToJSON(src)
+		assert(t, err != nil)
+	}
+}
+
+func TestDocument_ScriptJSON(t *testing.T) {
+	srv := newTestServer(t, `<html><head>
+			<script type="application/ld+json">{"@type": "Product", "name": "Phone"}</script>
+			<script id="__NEXT_DATA__" type="application/json">{"props":{"page":"/home"}}</script>
+			<script src="/app.js"></script>
+			<script>
+				window.__INITIAL_STATE__ = window.__INITIAL_STATE__ || {};
+				if (window.__INITIAL_STATE__ == null) {}
+				window.__INITIAL_STATE__ = {user: {id: 10, name: 'Alice',}, items: [1, 2, 3,]};
+				var config = JSON.parse("{\"lang\":\"en\",\"debug\":false}");
+				App.init({options: {theme: "dark"}});
+			</script>
+		</head></html>`)
+
+	doc := newDocument(srv.URL, NewClient(), nil)
+
+	obj, err := doc.ScriptJSObject("#__NEXT_DATA__")
+	assert(t, err == nil)
+	assert(t, jsonStr(obj) == `{"props":{"page":"/home"}}`)
+
+	obj, err = doc.ScriptJSObject(`[type="application/ld+json"]`)
+	assert(t, err == nil)
+	assert(t, obj.GetStr("name") == "Phone")
+
+	var state struct {
+		User struct {
+			ID   int    `json:"id"`
+			Name string `json:"name"`
+		} `json:"user"`
+		Items []int `json:"items"`
+	}
+	assert(t, doc.ScriptJSON("window.__INITIAL_STATE__", &state) == nil)
+	assert(t, state.User.ID == 10 && state.User.Name == "Alice" && len(state.Items) == 3)
+
+	obj, err = doc.ScriptJSObject("config")
+	assert(t, err == nil)
+	assert(t, jsonStr(obj) == `{"debug":false,"lang":"en"}`)
+
+	obj, err = doc.ScriptJSObject("options")
+	assert(t, err == nil)
+	assert(t, obj.GetStr("theme") == "dark")
+
+	_, err = doc.ScriptJSObject("unknown")
+	assert(t, err != nil)
+}
@@ -0,0 +1,132 @@
+package httpdoc
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/andybalholm/cascadia"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// fragmentContexts are contexts for parsing of elements which can't be direct children of <body>
+var fragmentContexts = map[string]atom.Atom{
+	"td": atom.Tr, "th": atom.Tr, "tr": atom.Tbody,
+	"tbody": atom.Table, "thead": atom.Table, "tfoot": atom.Table, "caption": atom.Table, "colgroup": atom.Table,
+	"option": atom.Select, "optgroup": atom.Select,
+}
+
+// Find gets html-elements of document matching css-selector (e.g. "div.content > a[href]").
+// Invalid selector matches nothing (see ValidateSelector).
+func (d *Document) Find(selector string) HTMLElements {
+	sel, err := cascadia.Compile(selector)
+	if err != nil {
+		return nil
+	}
+	return findHTMLElements(d, d.htmlRoot(), sel)
+}
+
+// Find gets descendant html-elements matching css-selector
+func (e *HTMLElement) Find(selector string) HTMLElements {
+	sel, err := cascadia.Compile(selector)
+	if e == nil || err != nil {
+		return nil
+	}
+	return findHTMLElements(e.Document, e.htmlNode(), sel)
+}
+
+// Find gets descendant html-elements of all elements matching css-selector
+func (ee HTMLElements) Find(selector string) (res HTMLElements) {
+	sel, err := cascadia.Compile(selector)
+	if err != nil {
+		return nil
+	}
+	found := map[*html.Node]bool{}
+	for _, e := range ee {
+		for _, el := range findHTMLElements(e.Document, e.htmlNode(), sel) {
+			if !found[el.node] {
+				found[el.node] = true
+				res = append(res, el)
+			}
+		}
+	}
+	return
+}
+
+// ValidateSelector returns error of syntax of css-selector
+func ValidateSelector(selector string) error {
+	_, err := cascadia.Compile(selector)
+	return err
+}
+
+func findHTMLElements(d *Document, root *html.Node, sel cascadia.Selector) (ee HTMLElements) {
+	if root == nil {
+		return nil
+	}
+	for _, n := range cascadia.QueryAll(root, sel) {
+		ee = append(ee, newHTMLElement(d, n))
+	}
+	return
+}
+
+// newHTMLElement makes html-element of parsed node
+func newHTMLElement(d *Document, n *html.Node) *HTMLElement {
+	e := &HTMLElement{
+		Document:   d,
+		TagName:    n.Data,
+		Attributes: make(map[string]string, len(n.Attr)),
+		node:       n,
+	}
+	for _, a := range n.Attr {
+		if _, ok := e.Attributes[a.Key]; !ok && a.Namespace == "" {
+			e.Attributes[a.Key] = a.Val
+		}
+	}
+	var sb strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		html.Render(&sb, c)
+	}
+	e.InnerHTML = sb.String()
+	return e
+}
+
+// htmlRoot returns html-tree of document. Tree is cached until Body is replaced and must not be modified.
+func (d *Document) htmlRoot() *html.Node {
+	body := d.Content()
+	d.cacheMx.Lock()
+	defer d.cacheMx.Unlock()
+	if d.root == nil || !sameBytes(body, d.rootBody) {
+		root, err := html.Parse(bytes.NewReader(body))
+		if err != nil {
+			root = &html.Node{Type: html.DocumentNode}
+		}
+		d.root, d.rootBody = root, body
+	}
+	return d.root
+}
+
+// htmlNode returns node of element (element found by tag name is parsed from its html)
+func (e *HTMLElement) htmlNode() *html.Node {
+	if e.node != nil {
+		return e.node
+	}
+	context := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	if a, ok := fragmentContexts[e.TagName]; ok {
+		context = &html.Node{Type: html.ElementNode, Data: a.String(), DataAtom: a}
+	}
+	nodes, err := html.ParseFragment(strings.NewReader(e.String()), context)
+	if err != nil {
+		return nil
+	}
+	for _, n := range nodes {
+		if n.Type == html.ElementNode && n.Data == e.TagName {
+			e.node = n
+			return n
+		}
+	}
+	for _, n := range nodes {
+		context.AppendChild(n)
+	}
+	e.node = context
+	return context
+}
@@ -0,0 +1,148 @@
+package httpdoc
+
+import (
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Session bundles http-client (with cookie-jar), default headers, base url, proxy and middlewares.
+// All documents created by session and by NewDoc of session documents share these settings.
+type Session struct {
+	Client *http.Client
+
+	mx          sync.RWMutex
+	header      http.Header
+	baseURL     *url.URL
+	proxyPool   *ProxyPool
+	middlewares Middlewares
+	signers     []RequestSigner
+}
+
+// NewSession creates session with new http-client and copy of DefaultHeader
+func NewSession(opts ...ClientOption) *Session {
+	return &Session{
+		Client: NewClient(opts...),
+		header: DefaultHeader.Clone(),
+	}
+}
+
+// NewDocument creates document for url or path relative to session base url
+func (s *Session) NewDocument(path string) *Document {
+	s.mx.RLock()
+	baseURL, client := s.baseURL, s.Client
+	s.mx.RUnlock()
+
+	if baseURL != nil {
+		u, err := url.Parse(path)
+		panicOnErr(err)
+		path = baseURL.ResolveReference(u).String()
+	}
+	return newDocument(path, client, s)
+}
+
+// LoadJSON loads url (or path relative to base url) and decodes json-response to v
+func (s *Session) LoadJSON(path string, v any) error {
+	doc := s.NewDocument(path)
+	if err := doc.Load(); err != nil {
+		return err
+	}
+	return doc.GetJSON(v)
+}
+
+func (s *Session) BaseURL() *url.URL {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	return s.baseURL
+}
+
+func (s *Session) SetBaseURL(baseURL string) *Session {
+	u, err := url.Parse(baseURL)
+	panicOnErr(err)
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.baseURL = u
+	return s
+}
+
+// Header returns copy of default headers of session
+func (s *Session) Header() http.Header {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	return s.header.Clone()
+}
+
+func (s *Session) SetHeader(name, value string) *Session {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.header.Set(name, value)
+	return s
+}
+
+func (s *Session) DeleteHeader(name string) *Session {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.header.Del(name)
+	return s
+}
+
+func (s *Session) SetUserAgent(ua string) *Session {
+	return s.SetHeader("User-Agent", ua)
+}
+
+// SetProxy makes all session documents to send requests through proxy. Session cookies are kept.
+func (s *Session) SetProxy(proxyAddr string, opts ...ClientOption) *Session {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if proxyAddr != "" {
+		s.Client = newProxyClientFrom(s.Client, proxyAddr, opts)
+	}
+	return s
+}
+
+func (s *Session) SetProxyPool(pool *ProxyPool) *Session {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.proxyPool = pool
+	return s
+}
+
+// Use adds middleware for all session documents
+func (s *Session) Use(fn Middleware) *Session {
+	s.middlewares.Use(fn)
+	return s
+}
+
+// Middlewares returns middleware chain of session
+func (s *Session) Middlewares() *Middlewares {
+	return &s.middlewares
+}
+
+// SetRetry makes session documents to resend failed requests (see Retry)
+func (s *Session) SetRetry(maxRetries int, delay time.Duration) *Session {
+	return s.Use(Retry(maxRetries, delay))
+}
+
+func (s *Session) newRequestHeader() http.Header {
+	if s == nil {
+		return DefaultHeader.Clone()
+	}
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	return s.header.Clone()
+}
+
+func (s *Session) getProxyPool() *ProxyPool {
+	if s == nil {
+		return nil
+	}
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	return s.proxyPool
+}
+
+// Session returns session of document or nil
+func (d *Document) Session() *Session {
+	return d.session
+}
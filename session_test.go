@@ -0,0 +1,41 @@
+package httpdoc
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestSession(t *testing.T) {
+	var attempts int
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			http.SetCookie(w, &http.Cookie{Name: "sid", Value: "123"})
+			w.Write([]byte(`<a href="/profile">profile</a>`))
+		case "/api/flaky":
+			if attempts++; attempts < 3 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			w.Write([]byte(`<title>ok</title>`))
+		default:
+			c, _ := r.Cookie("sid")
+			w.Write([]byte("<title>" + r.Header.Get("User-Agent") + " " + c.String() + "</title>"))
+		}
+	})
+
+	s := NewSession().SetBaseURL(srv.URL+"/api/").SetUserAgent("test-agent").SetRetry(2, time.Millisecond)
+
+	doc := s.NewDocument("/login").Links().First().Doc()
+
+	assert(t, doc.Session() == s)
+	assert(t, "test-agent sid=123" == doc.Title())
+	assert(t, DefaultHeader.Get("User-Agent") != "test-agent")
+
+	doc = s.NewDocument("flaky")
+	assert(t, srv.URL+"/api/flaky" == doc.URL().String())
+	assert(t, doc.Load() == nil)
+	assert(t, "ok" == doc.Title())
+	assert(t, 3 == attempts)
+}
@@ -0,0 +1,274 @@
+package httpdoc
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RequestSigner signs request right before sending (after body and headers are finalized); body is nil for requests without body
+type RequestSigner interface {
+	SignRequest(req *http.Request, body []byte) error
+}
+
+type SignerFunc func(req *http.Request, body []byte) error
+
+func (fn SignerFunc) SignRequest(req *http.Request, body []byte) error {
+	return fn(req, body)
+}
+
+// Sign adds request signer to the document
+func (d *Document) Sign(signer RequestSigner) *Document {
+	d.signers = append(d.signers, signer)
+	return d
+}
+
+// Sign adds request signer for all session documents
+func (s *Session) Sign(signer RequestSigner) *Session {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.signers = append(s.signers, signer)
+	return s
+}
+
+func (d *Document) signRequest() error {
+	var signers []RequestSigner
+	if s := d.session; s != nil {
+		s.mx.RLock()
+		signers = append(signers, s.signers...)
+		s.mx.RUnlock()
+	}
+	signers = append(signers, d.signers...)
+	if len(signers) == 0 {
+		return nil
+	}
+	body, err := d.requestBody()
+	if err != nil {
+		return err
+	}
+	for _, signer := range signers {
+		if err := signer.SignRequest(d.Request, body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//--------------- AWS Signature Version 4 -----------------
+
+// AWSSigner signs requests with AWS Signature Version 4
+type AWSSigner struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Region          string
+	Service         string
+	UnsignedPayload bool             // don't hash request body (S3 only)
+	Now             func() time.Time // current time (for tests)
+}
+
+func (s *AWSSigner) SignRequest(req *http.Request, body []byte) error {
+	now := time.Now
+	if s.Now != nil {
+		now = s.Now
+	}
+	t := now().UTC()
+	amzDate := t.Format("20060102T150405Z")
+	date := t.Format("20060102")
+
+	payloadHash := hexSHA256(body)
+	if s.UnsignedPayload {
+		payloadHash = "UNSIGNED-PAYLOAD"
+	}
+	req.Header.Set("X-Amz-Date", amzDate)
+	if s.Service == "s3" {
+		req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+	if s.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.SessionToken)
+	}
+
+	// canonical headers
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for name, values := range req.Header {
+		if name = strings.ToLower(name); name == "content-type" || strings.HasPrefix(name, "x-amz-") {
+			vals := make([]string, len(values))
+			for i, v := range values {
+				vals[i] = strings.Join(strings.Fields(v), " ")
+			}
+			headers[name] = strings.Join(vals, ",")
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	// canonical uri
+	path := req.URL.EscapedPath()
+	if s.Service == "s3" {
+		path = req.URL.Path
+	}
+	if path == "" {
+		path = "/"
+	}
+	canonicalURI := awsURIEncode(path, false)
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI,
+		awsCanonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.Region + "/" + s.Service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hexSHA256([]byte(canonicalRequest))
+
+	key := hmacSum(sha256.New, []byte("AWS4"+s.SecretAccessKey), date)
+	key = hmacSum(sha256.New, key, s.Region)
+	key = hmacSum(sha256.New, key, s.Service)
+	key = hmacSum(sha256.New, key, "aws4_request")
+	signature := hex.EncodeToString(hmacSum(sha256.New, key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.AccessKeyID, scope, signedHeaders, signature))
+	return nil
+}
+
+// awsCanonicalQuery returns encoded query params sorted by name and then by value
+func awsCanonicalQuery(query url.Values) string {
+	var params [][2]string
+	for name, values := range query {
+		for _, v := range values {
+			params = append(params, [2]string{awsURIEncode(name, true), awsURIEncode(v, true)})
+		}
+	}
+	sort.Slice(params, func(i, j int) bool {
+		if params[i][0] != params[j][0] {
+			return params[i][0] < params[j][0]
+		}
+		return params[i][1] < params[j][1]
+	})
+	ss := make([]string, len(params))
+	for i, p := range params {
+		ss[i] = p[0] + "=" + p[1]
+	}
+	return strings.Join(ss, "&")
+}
+
+// awsURIEncode encodes string as required by AWS: all bytes except unreserved characters are percent-encoded
+func awsURIEncode(s string, encodeSlash bool) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' || c == '/' && !encodeSlash {
+			sb.WriteByte(c)
+		} else {
+			fmt.Fprintf(&sb, "%%%02X", c)
+		}
+	}
+	return sb.String()
+}
+
+//--------------- HMAC signature -----------------
+
+// HMACSigner signs requests by HMAC of request-target and headers (draft-cavage-http-signatures); body is signed by header Digest
+type HMACSigner struct {
+	KeyID      string
+	Secret     []byte
+	Algorithm  string           // algorithm name in signature header ("hmac-sha256" by default)
+	Hash       func() hash.Hash // sha256.New by default
+	Headers    []string         // signed headers; default is "(request-target)", "host", "date", "digest"
+	HeaderName string           // "Signature" by default; use "Authorization" to send as authorization scheme
+}
+
+func (s *HMACSigner) SignRequest(req *http.Request, body []byte) error {
+	newHash, algorithm := s.Hash, s.Algorithm
+	if newHash == nil {
+		newHash = sha256.New
+	}
+	if algorithm == "" {
+		algorithm = "hmac-sha256"
+	}
+	headerNames := s.Headers
+	if len(headerNames) == 0 {
+		headerNames = []string{"(request-target)", "host", "date", "digest"}
+	}
+	var signed []string
+	var lines []string
+	for _, name := range headerNames {
+		name = strings.ToLower(name)
+		var value string
+		switch name {
+		case "(request-target)":
+			value = strings.ToLower(req.Method) + " " + req.URL.RequestURI()
+		case "host":
+			if value = req.Host; value == "" {
+				value = req.URL.Host
+			}
+		case "date":
+			if req.Header.Get("Date") == "" {
+				req.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+			}
+			value = req.Header.Get("Date")
+		case "digest":
+			if body == nil {
+				continue
+			}
+			value = "SHA-256=" + base64.StdEncoding.EncodeToString(sha256Sum(body))
+			req.Header.Set("Digest", value)
+		default:
+			value = strings.TrimSpace(strings.Join(req.Header.Values(name), ", "))
+		}
+		signed = append(signed, name)
+		lines = append(lines, name+": "+value)
+	}
+	signature := base64.StdEncoding.EncodeToString(hmacSum(newHash, s.Secret, strings.Join(lines, "\n")))
+	params := fmt.Sprintf(`keyId="%s",algorithm="%s",headers="%s",signature="%s"`,
+		escapeQuotes(s.KeyID), algorithm, strings.Join(signed, " "), signature)
+
+	if strings.EqualFold(s.HeaderName, "Authorization") {
+		req.Header.Set("Authorization", "Signature "+params)
+	} else if s.HeaderName != "" {
+		req.Header.Set(s.HeaderName, params)
+	} else {
+		req.Header.Set("Signature", params)
+	}
+	return nil
+}
+
+func hmacSum(newHash func() hash.Hash, key []byte, data string) []byte {
+	h := hmac.New(newHash, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func sha256Sum(data []byte) []byte {
+	sum := sha256.Sum256(data)
+	return sum[:]
+}
+
+func hexSHA256(data []byte) string {
+	return hex.EncodeToString(sha256Sum(data))
+}
@@ -0,0 +1,75 @@
+package httpdoc
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAWSSigner(t *testing.T) {
+	// cases of AWS Signature Version 4 test suite
+	for path, signature := range map[string]string{
+		"/":                             "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31", // get-vanilla
+		"/?Param2=value2&Param1=value1": "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500", // get-vanilla-query-order-key-case
+		"/?Param1=value2&Param1=value1": "5772eed61e12b33fae39ee5e7012498b51d56abc0abb7c60486157bd471c4694", // get-vanilla-query-order-value
+	} {
+		req, _ := http.NewRequest("GET", "https://example.amazonaws.com"+path, nil)
+		signer := &AWSSigner{
+			AccessKeyID:     "AKIDEXAMPLE",
+			SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+			Region:          "us-east-1",
+			Service:         "service",
+			Now:             func() time.Time { return time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC) },
+		}
+		assert(t, signer.SignRequest(req, nil) == nil)
+		assert(t, req.Header.Get("Authorization") == "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, "+
+			"SignedHeaders=host;x-amz-date, Signature="+signature)
+	}
+}
+
+func TestAWSCanonicalQuery(t *testing.T) {
+	query, _ := url.ParseQuery("prefix-x=1&list-type=2&a1=3&prefix=4&a=6&a=5&list=7&b=x y")
+	assert(t, awsCanonicalQuery(query) == "a=5&a=6&a1=3&b=x%20y&list=7&list-type=2&prefix=4&prefix-x=1")
+}
+
+func TestHMACSigner_signature(t *testing.T) {
+	// request of examples of draft-cavage-http-signatures
+	body := []byte(`{"hello": "world"}`)
+	req, _ := http.NewRequest("POST", "https://example.com/foo?param=value&pet=dog", nil)
+	req.Header.Set("Date", "Sun, 05 Jan 2014 21:31:40 GMT")
+	assert(t, (&HMACSigner{KeyID: "Test", Secret: []byte("secret")}).SignRequest(req, body) == nil)
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("(request-target): post /foo?param=value&pet=dog\n" +
+		"host: example.com\n" +
+		"date: Sun, 05 Jan 2014 21:31:40 GMT\n" +
+		"digest: SHA-256=X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE="))
+	assert(t, req.Header.Get("Digest") == "SHA-256=X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=")
+	assert(t, req.Header.Get("Signature") == `keyId="Test",algorithm="hmac-sha256",headers="(request-target) host date digest",`+
+		`signature="`+base64.StdEncoding.EncodeToString(mac.Sum(nil))+`"`)
+}
+
+func TestHMACSigner(t *testing.T) {
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		sum := sha256.Sum256(body)
+		ok := r.Header.Get("Digest") == "SHA-256="+base64.StdEncoding.EncodeToString(sum[:]) &&
+			strings.Contains(r.Header.Get("Signature"), `headers="(request-target) host date digest"`) &&
+			strings.Contains(string(body), "Content-Disposition: form-data; name=\"file\"")
+		if !ok {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	})
+
+	doc := newDocument(srv.URL, NewClient(), nil)
+	doc.SetMultipartContent("file", io.NopCloser(strings.NewReader("file content")), "text/plain")
+	doc.Sign(&HMACSigner{KeyID: "key", Secret: []byte("secret")})
+
+	assert(t, doc.Load() == nil)
+}
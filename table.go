@@ -0,0 +1,251 @@
+package httpdoc
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+const maxTableSpan = 1000
+
+// Table is grid of html-table cells with expanded rowspan and colspan
+type Table struct {
+	Header [][]string // header rows (rows of <thead> or leading rows of <th>-cells)
+	Body   [][]string // data rows
+}
+
+// Tables gets collections of html-tags <table> (including nested tables)
+func (d *Document) Tables() HTMLElements {
+	return d.Find("table")
+}
+
+// Table parses html-element <table> to grid of cells texts
+func (e *HTMLElement) Table() *Table {
+	if e == nil {
+		return &Table{}
+	}
+	if n := e.htmlNode(); n != nil {
+		if n = findHTMLNode(n, "table"); n != nil {
+			return newTable(n)
+		}
+	}
+	return &Table{}
+}
+
+type tableRow struct {
+	cells  []*html.Node
+	header bool
+}
+
+func newTable(n *html.Node) *Table {
+	// collect rows of table (but not of nested tables)
+	var rows []tableRow
+	var addRows func(n *html.Node, inHead bool)
+	addRows = func(n *html.Node, inHead bool) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			switch c.Data {
+			case "thead":
+				addRows(c, true)
+			case "tbody", "tfoot":
+				addRows(c, false)
+			case "tr":
+				row := tableRow{header: inHead}
+				allTH := true
+				for td := c.FirstChild; td != nil; td = td.NextSibling {
+					if td.Type == html.ElementNode && (td.Data == "td" || td.Data == "th") {
+						row.cells = append(row.cells, td)
+						allTH = allTH && td.Data == "th"
+					}
+				}
+				row.header = row.header || allTH && len(row.cells) > 0
+				rows = append(rows, row)
+			}
+		}
+	}
+	addRows(n, false)
+
+	// expand rowspan and colspan
+	grid := make([][]string, len(rows))
+	filled := make([][]bool, len(rows))
+	width := 0
+	for i, row := range rows {
+		col := 0
+		for _, td := range row.cells {
+			for col < len(filled[i]) && filled[i][col] {
+				col++
+			}
+			text := htmlCellText(td)
+			colspan, rowspan := tableSpan(td, "colspan"), tableSpan(td, "rowspan")
+			for r := i; r < i+rowspan && r < len(rows); r++ {
+				for len(grid[r]) < col+colspan {
+					grid[r], filled[r] = append(grid[r], ""), append(filled[r], false)
+				}
+				for c := col; c < col+colspan; c++ {
+					grid[r][c], filled[r][c] = text, true
+				}
+			}
+			col += colspan
+		}
+		width = max(width, len(grid[i]))
+	}
+	t := &Table{}
+	inHeader := true
+	for i, cells := range grid {
+		for len(cells) < width {
+			cells = append(cells, "")
+		}
+		if inHeader = inHeader && rows[i].header; inHeader {
+			t.Header = append(t.Header, cells)
+		} else {
+			t.Body = append(t.Body, cells)
+		}
+	}
+	return t
+}
+
+func tableSpan(td *html.Node, attr string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(htmlAttr(td, attr)))
+	if err != nil || n < 1 {
+		return 1
+	}
+	return min(n, maxTableSpan)
+}
+
+// Rows returns all rows of table (header rows and data rows)
+func (t *Table) Rows() [][]string {
+	return append(append([][]string{}, t.Header...), t.Body...)
+}
+
+// Columns returns names of columns. Texts of multi-row header are joined by space (e.g. "Price Min", "Price Max").
+func (t *Table) Columns() []string {
+	var cols []string
+	for _, row := range t.Header {
+		for i, s := range row {
+			if i >= len(cols) {
+				cols = append(cols, s)
+			} else if s != "" && !strings.HasSuffix(cols[i], s) {
+				cols[i] = strings.TrimSpace(cols[i] + " " + s)
+			}
+		}
+	}
+	return cols
+}
+
+// Records returns data rows as maps of column name to cell text (first row is header if table has no header rows)
+func (t *Table) Records() []map[string]string {
+	cols, body := t.recordColumns()
+	records := make([]map[string]string, 0, len(body))
+	for _, row := range body {
+		rec := make(map[string]string, len(cols))
+		for i, col := range cols {
+			if i < len(row) {
+				rec[col] = row[i]
+			} else {
+				rec[col] = ""
+			}
+		}
+		records = append(records, rec)
+	}
+	return records
+}
+
+func (t *Table) recordColumns() (cols []string, body [][]string) {
+	cols, body = t.Columns(), t.Body
+	if len(t.Header) == 0 && len(body) > 0 {
+		cols, body = append([]string{}, body[0]...), body[1:]
+	}
+	for _, row := range body {
+		for len(cols) < len(row) {
+			cols = append(cols, "")
+		}
+	}
+	used := map[string]int{}
+	for i, col := range cols {
+		if col == "" {
+			col = strconv.Itoa(i + 1)
+		}
+		if used[col]++; used[col] > 1 {
+			col += "_" + strconv.Itoa(used[col])
+		}
+		cols[i] = col
+	}
+	return
+}
+
+// WriteCSV writes table to w in CSV format
+func (t *Table) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if cols := t.Columns(); len(cols) > 0 {
+		if err := cw.Write(cols); err != nil {
+			return err
+		}
+	}
+	if err := cw.WriteAll(t.Body); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// Decode decodes records of table to slice of structs; fields are matched to columns by tag `table:"Column"` or by name
+func (t *Table) Decode(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Slice {
+		return errors.New("httpdoc.Table.Decode: pointer to slice expected")
+	}
+	slice := rv.Elem()
+	elemType := slice.Type().Elem()
+	structType := elemType
+	if structType.Kind() == reflect.Pointer {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return errors.New("httpdoc.Table.Decode: slice of structs expected")
+	}
+	cols, _ := t.recordColumns()
+	fields := tableFields(structType, cols)
+	records := t.Records()
+	slice.Set(reflect.MakeSlice(slice.Type(), 0, len(records)))
+	for i, rec := range records {
+		item := reflect.New(structType).Elem()
+		for col, index := range fields {
+			if err := setValue(item.FieldByIndex(index), rec[col]); err != nil {
+				return fmt.Errorf("httpdoc.Table.Decode: row %d, column %q: %w", i+1, col, err)
+			}
+		}
+		if elemType.Kind() == reflect.Pointer {
+			item = item.Addr()
+		}
+		slice.Set(reflect.Append(slice, item))
+	}
+	return nil
+}
+
+// tableFields maps column names to indexes of struct fields
+func tableFields(typ reflect.Type, cols []string) map[string][]int {
+	fields := map[string][]int{}
+	for _, f := range reflect.VisibleFields(typ) {
+		if !f.IsExported() || f.Anonymous {
+			continue
+		}
+		name := f.Tag.Get("table")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		for _, col := range cols {
+			if strings.EqualFold(col, name) {
+				fields[col] = f.Index
+				break
+			}
+		}
+	}
+	return fields
+}
@@ -0,0 +1,83 @@
+package httpdoc
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestHTMLElement_Table(t *testing.T) {
+	srv := newTestServer(t, `<table>
+			<thead>
+				<tr><th rowspan="2">Product</th><th colspan="2">Price</th><th rowspan="2">Date</th></tr>
+				<tr><th>Min</th><th>Max</th></tr>
+			</thead>
+			<tbody>
+				<tr><td><a href="/p/1">Phone</a></td><td>$1,099.50</td><td>1,299</td><td rowspan="2">2024-05-01</td></tr>
+				<tr><td>Tablet<br>Pro</td><td colspan="2">899</td></tr>
+			</tbody>
+		</table>
+		<table><tr><td>a</td><td>b</td></tr><tr><td>1</td><td>2</td></tr></table>`)
+
+	doc := newDocument(srv.URL, NewClient(), nil)
+	tables := doc.Tables()
+	assert(t, len(tables) == 2)
+
+	table := tables[0].Table()
+	assert(t, jsonStr(table.Columns()) == `["Product","Price Min","Price Max","Date"]`)
+	assert(t, jsonStr(table.Body) == `[["Phone","$1,099.50","1,299","2024-05-01"],["Tablet Pro","899","899","2024-05-01"]]`)
+	assert(t, len(table.Rows()) == 4)
+	assert(t, table.Records()[1]["Price Max"] == "899")
+
+	var buf bytes.Buffer
+	assert(t, table.WriteCSV(&buf) == nil)
+	assert(t, buf.String() == "Product,Price Min,Price Max,Date\nPhone,\"$1,099.50\",\"1,299\",2024-05-01\nTablet Pro,899,899,2024-05-01\n")
+
+	var products []struct {
+		Product  string
+		MinPrice float64 `table:"price min"`
+		MaxPrice *int    `table:"Price Max"`
+		Date     time.Time
+		Ignored  string `table:"-"`
+	}
+	assert(t, table.Decode(&products) == nil)
+	assert(t, len(products) == 2)
+	assert(t, products[0].Product == "Phone" && products[0].MinPrice == 1099.5 && *products[0].MaxPrice == 1299)
+	assert(t, products[1].Date.Equal(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)))
+
+	var bad []struct {
+		Product int
+	}
+	assert(t, table.Decode(&bad) != nil)
+
+	// table without header
+	records := tables[1].Table().Records()
+	assert(t, jsonStr(records) == `[{"a":"1","b":"2"}]`)
+}
+
+func TestHTMLElement_Table_nested(t *testing.T) {
+	doc := newDocument("http://example.com/", NewClient(), nil).SetResponse(newHTMLResponse(), []byte(`<html><body>
+		<table>
+			<tr><td>menu</td><td><table><tr><td>x</td><td>y</td></tr></table></td></tr>
+			<tr><td>footer</td><td>z</td></tr>
+		</table>`))
+
+	tables := doc.Tables()
+	assert(t, len(tables) == 2)
+	assert(t, jsonStr(tables[0].Table().Rows()) == `[["menu","x y"],["footer","z"]]`)
+	assert(t, jsonStr(tables[1].Table().Rows()) == `[["x","y"]]`)
+}
+
+func TestSetValue_numbers(t *testing.T) {
+	for s, expected := range map[string]float64{"$1,234.50": 1234.5, "-1,234,567": -1234567, "12 %": 12, "1 000": 1000, "€ 0.5": 0.5} {
+		var f float64
+		assert(t, setValue(reflect.ValueOf(&f).Elem(), s) == nil && f == expected)
+	}
+	for _, s := range []string{"1,5", "1.234,56", "12,34"} {
+		var f float64
+		assert(t, setValue(reflect.ValueOf(&f).Elem(), s) != nil)
+	}
+	var i int
+	assert(t, setValue(reflect.ValueOf(&i).Elem(), "1,000") == nil && i == 1000)
+}
@@ -0,0 +1,262 @@
+package httpdoc
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/cascadia"
+	"golang.org/x/net/html"
+)
+
+// HTMLUnmarshaler is implemented by types which unmarshal themselves from html-element
+type HTMLUnmarshaler interface {
+	UnmarshalHTML(e *HTMLElement) error
+}
+
+// ValueParser converts text of html-element (or value of attribute) to value of struct field
+type ValueParser func(s string) (any, error)
+
+var (
+	valueParsersMx sync.RWMutex
+	valueParsers   = map[string]ValueParser{}
+)
+
+// RegisterParser registers parser of values which is used by fields with tag option "parser=name"
+func RegisterParser(name string, parser ValueParser) {
+	valueParsersMx.Lock()
+	defer valueParsersMx.Unlock()
+	valueParsers[name] = parser
+}
+
+func getValueParser(name string) ValueParser {
+	valueParsersMx.RLock()
+	defer valueParsersMx.RUnlock()
+	return valueParsers[name]
+}
+
+var (
+	typeHTMLElement     = reflect.TypeOf((*HTMLElement)(nil))
+	typeHTMLElements    = reflect.TypeOf(HTMLElements(nil))
+	typeHTMLUnmarshaler = reflect.TypeOf((*HTMLUnmarshaler)(nil)).Elem()
+	typeTextUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// Unmarshal fills fields of struct by tags `httpdoc:"selector[,attr=name][,html][,abs][,parser=name][,required]"`.
+// Empty selector refers to current element; slices get all matched elements, nested structs are filled from scope of element.
+func (d *Document) Unmarshal(v any) error {
+	if err := d.Load(); err != nil {
+		return err
+	}
+	root := d.htmlRoot()
+	if n := findHTMLNode(root, "html"); n != nil {
+		root = n
+	}
+	return unmarshalHTML(newHTMLElement(d, root), v)
+}
+
+// Unmarshal fills fields of struct by css-selectors from tags `httpdoc:"selector"` in scope of element
+// (see Document.Unmarshal)
+func (e *HTMLElement) Unmarshal(v any) error {
+	if e == nil {
+		return errors.New("httpdoc: unmarshal of nil element")
+	}
+	if e.htmlNode() == nil {
+		return errors.New("httpdoc: unmarshal of invalid element")
+	}
+	return unmarshalHTML(e, v)
+}
+
+func unmarshalHTML(e *HTMLElement, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("httpdoc: unmarshal to %T, pointer to struct expected", v)
+	}
+	return unmarshalStruct(e, rv.Elem())
+}
+
+// htmlTag is parsed tag `httpdoc:"..."` of struct field
+type htmlTag struct {
+	selector string
+	attr     string
+	html     bool
+	abs      bool
+	parser   string
+	required bool
+}
+
+// parseHTMLTag parses tag; selector can contain commas (e.g. "h1, h2"), so options are taken from the end of tag
+func parseHTMLTag(tag string) (t htmlTag) {
+	parts := strings.Split(tag, ",")
+	for len(parts) > 1 {
+		opt := strings.TrimSpace(parts[len(parts)-1])
+		switch key, val, _ := strings.Cut(opt, "="); key {
+		case "attr":
+			t.attr = val
+		case "parser":
+			t.parser = val
+		case "html":
+			t.html = true
+		case "abs":
+			t.abs = true
+		case "required":
+			t.required = true
+		default:
+			t.selector = strings.TrimSpace(strings.Join(parts, ","))
+			return
+		}
+		parts = parts[:len(parts)-1]
+	}
+	t.selector = strings.TrimSpace(parts[0])
+	return
+}
+
+func unmarshalStruct(e *HTMLElement, v reflect.Value) error {
+	for i, typ := 0, v.Type(); i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		tagStr, hasTag := f.Tag.Lookup("httpdoc")
+		if !f.IsExported() || tagStr == "-" {
+			continue
+		}
+		fv := v.Field(i)
+		if !hasTag {
+			// untagged structs (and embedded structs) are filled from current scope
+			if ft := f.Type; ft.Kind() == reflect.Struct && !isHTMLValueType(ft) {
+				if err := unmarshalStruct(e, fv); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		tag := parseHTMLTag(tagStr)
+		if err := unmarshalField(e, fv, tag); err != nil {
+			return fmt.Errorf("httpdoc: field %s (%q): %w", f.Name, tagStr, err)
+		}
+	}
+	return nil
+}
+
+func unmarshalField(e *HTMLElement, v reflect.Value, tag htmlTag) error {
+	var nodes []*html.Node
+	if tag.selector == "" {
+		nodes = []*html.Node{e.node}
+	} else {
+		sel, err := cascadia.Compile(tag.selector)
+		if err != nil {
+			return err
+		}
+		nodes = cascadia.QueryAll(e.node, sel)
+	}
+	if tag.attr != "" { // elements without attribute are not matched
+		var withAttr []*html.Node
+		for _, n := range nodes {
+			if _, ok := getHTMLAttr(n, tag.attr); ok {
+				withAttr = append(withAttr, n)
+			}
+		}
+		nodes = withAttr
+	}
+	if len(nodes) == 0 {
+		if tag.required {
+			return errors.New("element not found")
+		}
+		return nil
+	}
+	if v.Type() == typeHTMLElements {
+		ee := make(HTMLElements, len(nodes))
+		for i, n := range nodes {
+			ee[i] = newHTMLElement(e.Document, n)
+		}
+		v.Set(reflect.ValueOf(ee))
+		return nil
+	}
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 && !isHTMLValueType(v.Type()) {
+		slice := reflect.MakeSlice(v.Type(), len(nodes), len(nodes))
+		for i, n := range nodes {
+			if err := unmarshalValue(newHTMLElement(e.Document, n), slice.Index(i), tag); err != nil {
+				return fmt.Errorf("item %d: %w", i, err)
+			}
+		}
+		v.Set(slice)
+		return nil
+	}
+	return unmarshalValue(newHTMLElement(e.Document, nodes[0]), v, tag)
+}
+
+// isHTMLValueType reports whether type is converted from text (but not filled as sub-scope)
+func isHTMLValueType(t reflect.Type) bool {
+	switch {
+	case t == typeTime || t == typeURL:
+		return true
+	case reflect.PointerTo(t).Implements(typeTextUnmarshaler) || t.Implements(typeTextUnmarshaler):
+		return true
+	}
+	return false
+}
+
+func unmarshalValue(e *HTMLElement, v reflect.Value, tag htmlTag) error {
+	switch t := v.Type(); {
+	case t == typeHTMLElement:
+		v.Set(reflect.ValueOf(e))
+		return nil
+
+	case t.Implements(typeHTMLUnmarshaler) && t.Kind() == reflect.Pointer:
+		if v.IsNil() {
+			v.Set(reflect.New(t.Elem()))
+		}
+		return v.Interface().(HTMLUnmarshaler).UnmarshalHTML(e)
+
+	case reflect.PointerTo(t).Implements(typeHTMLUnmarshaler):
+		return v.Addr().Interface().(HTMLUnmarshaler).UnmarshalHTML(e)
+
+	case t.Kind() == reflect.Pointer && t.Elem().Kind() == reflect.Struct && !isHTMLValueType(t.Elem()) && tag.parser == "":
+		if v.IsNil() {
+			v.Set(reflect.New(t.Elem()))
+		}
+		return unmarshalStruct(e, v.Elem())
+
+	case t.Kind() == reflect.Struct && !isHTMLValueType(t) && tag.parser == "":
+		return unmarshalStruct(e, v)
+	}
+
+	var s string
+	switch {
+	case tag.attr != "":
+		s = e.Attributes[tag.attr]
+	case tag.html:
+		s = e.InnerHTML
+	default:
+		s = htmlCellText(e.node)
+	}
+	if t := v.Type(); tag.abs || t == typeURL || t.Kind() == reflect.Pointer && t.Elem() == typeURL {
+		if s = strings.TrimSpace(s); s != "" {
+			s = e.Document.AbsURL(s)
+		}
+	}
+	if tag.parser != "" {
+		parser := getValueParser(tag.parser)
+		if parser == nil {
+			return fmt.Errorf("unknown parser %q", tag.parser)
+		}
+		val, err := parser(s)
+		if err != nil {
+			return err
+		}
+		rv := reflect.ValueOf(val)
+		switch {
+		case val == nil:
+			v.Set(reflect.Zero(v.Type()))
+		case rv.Type().AssignableTo(v.Type()):
+			v.Set(rv)
+		case rv.Type().ConvertibleTo(v.Type()):
+			v.Set(rv.Convert(v.Type()))
+		default:
+			return fmt.Errorf("parser %q returned %T, but %s expected", tag.parser, val, v.Type())
+		}
+		return nil
+	}
+	return setValue(v, s)
+}
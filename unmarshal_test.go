@@ -0,0 +1,113 @@
+package httpdoc
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testCatalogHTML = `<html><body>
+	<h1 class="title"> Phones &amp; tablets </h1>
+	<p class="updated">Updated: 15.03.2024</p>
+	<ul class="items">
+		<li data-id="1"><a href="/p/1">Phone</a> <span class="price">$1,099.50</span> <span class="stock">12</span></li>
+		<li data-id="2"><a href="/p/2">Tablet <b>Pro</b></a> <span class="price">899</span></li>
+	</ul>
+	<div class="pager"><a class="prev">1</a> <a class="next" href="?page=3">3</a></div>
+</body></html>`
+
+type testProduct struct {
+	ID    int      `httpdoc:",attr=data-id"`
+	Name  string   `httpdoc:"a"`
+	URL   *url.URL `httpdoc:"a,attr=href"`
+	Price float64  `httpdoc:".price"`
+	Stock *int     `httpdoc:".stock"`
+}
+
+type testUpperText string
+
+func (s *testUpperText) UnmarshalHTML(e *HTMLElement) error {
+	*s = testUpperText(strings.ToUpper(e.InnerText()))
+	return nil
+}
+
+func TestDocument_Unmarshal(t *testing.T) {
+	srv := newTestServer(t, testCatalogHTML)
+
+	RegisterParser("updated", func(s string) (any, error) {
+		return time.Parse("02.01.2006", strings.TrimPrefix(s, "Updated: "))
+	})
+
+	var page struct {
+		Title    string        `httpdoc:"h1.title"`
+		Upper    testUpperText `httpdoc:"h1"`
+		Updated  time.Time     `httpdoc:"p.updated,parser=updated"`
+		Products []testProduct `httpdoc:"ul.items li"`
+		Names    []string      `httpdoc:"ul.items a"`
+		First    *testProduct  `httpdoc:"ul.items li"`
+		Missing  *testProduct  `httpdoc:"ol li"`
+		Links    HTMLElements  `httpdoc:"a[href]"`
+		Pager    struct {
+			Next string `httpdoc:"a, span,attr=href,abs"`
+			HTML string `httpdoc:",html"`
+		} `httpdoc:".pager"`
+	}
+	doc := newDocument(srv.URL+"/catalog?page=2", NewClient(), nil)
+	err := doc.Unmarshal(&page)
+
+	assert(t, err == nil)
+	assert(t, page.Title == "Phones & tablets")
+	assert(t, page.Upper == "PHONES & TABLETS")
+	assert(t, page.Updated.Equal(time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)))
+	assert(t, len(page.Products) == 2)
+	assert(t, page.Products[0].ID == 1 && page.Products[0].Name == "Phone" && page.Products[0].Price == 1099.5)
+	assert(t, page.Products[0].URL.String() == srv.URL+"/p/1")
+	assert(t, page.Products[0].Stock != nil && *page.Products[0].Stock == 12)
+	assert(t, page.Products[1].Name == "Tablet Pro" && page.Products[1].Price == 899 && page.Products[1].Stock == nil)
+	assert(t, strings.Join(page.Names, "|") == "Phone|Tablet Pro")
+	assert(t, page.First != nil && page.First.ID == 1)
+	assert(t, page.Missing == nil)
+	assert(t, len(page.Links) == 3 && page.Links[2].Attributes["class"] == "next")
+	assert(t, page.Pager.Next == srv.URL+"/catalog?page=3")
+	assert(t, page.Pager.HTML == `<a class="prev">1</a> <a class="next" href="?page=3">3</a>`)
+
+	// errors
+	var required struct {
+		Name string `httpdoc:"h2,required"`
+	}
+	err = doc.Unmarshal(&required)
+	assert(t, err != nil && err.Error() == `httpdoc: field Name ("h2,required"): element not found`)
+
+	var invalid struct {
+		Count int `httpdoc:"h1"`
+	}
+	assert(t, doc.Unmarshal(&invalid) != nil)
+	assert(t, doc.Unmarshal(invalid) != nil)
+}
+
+func TestHTMLElement_Find(t *testing.T) {
+	srv := newTestServer(t, testCatalogHTML)
+
+	doc := newDocument(srv.URL, NewClient(), nil)
+	assert(t, len(doc.Find("ul.items > li")) == 2)
+	assert(t, doc.Find("li:nth-child(2) .price").First().InnerText() == "899")
+	assert(t, len(doc.Find("li").Find("a")) == 2)
+
+	// elements found by tag name are searched by selectors too
+	ul := doc.GetElementsByTagName("ul").First()
+	assert(t, ul.Find("a").Last().Attributes["href"] == "/p/2")
+
+	var p testProduct
+	assert(t, ul.Find("li").First().Unmarshal(&p) == nil)
+	assert(t, p.ID == 1 && p.Name == "Phone")
+
+	// invalid selectors match nothing
+	assert(t, ValidateSelector("li[") != nil && ValidateSelector("li > a") == nil)
+	assert(t, doc.Find("li[") == nil && ul.Find("li[") == nil && doc.Find("li").Find("li[") == nil)
+
+	// html-tree is parsed once and is parsed again after replacing of body
+	assert(t, doc.htmlRoot() == doc.htmlRoot())
+	doc.Body = []byte(`<ul class="items"><li>1</li></ul>`)
+	assert(t, len(doc.Find("ul.items > li")) == 1)
+}
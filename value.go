@@ -0,0 +1,155 @@
+package httpdoc
+
+import (
+	"encoding"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	time.RFC1123Z,
+	time.RFC1123,
+	"January 2, 2006",
+	"Jan 2, 2006",
+	"2 January 2006",
+	"2 Jan 2006",
+	"02.01.2006",
+}
+
+// parseTime parses time in one of common formats
+func parseTime(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+var (
+	typeTime     = reflect.TypeOf(time.Time{})
+	typeDuration = reflect.TypeOf(time.Duration(0))
+	typeURL      = reflect.TypeOf(url.URL{})
+)
+
+var (
+	// numberReplacer removes spaces, currency signs and percents from numbers like "$1 234.50", "12 %"
+	numberReplacer = strings.NewReplacer(" ", "", "\u00a0", "", "_", "", "$", "", "€", "", "£", "", "¥", "", "₽", "", "%", "")
+
+	// reThousands matches number with commas as thousands separators ("1,234.50")
+	reThousands = regexp.MustCompile(`^[-+]?\d{1,3}(?:,\d{3})+(?:\.\d*)?$`)
+)
+
+// normNumber removes thousands separators, currency signs and percents from number.
+// Comma which is not thousands separator ("1,5") is kept, so number is invalid.
+func normNumber(s string) string {
+	if s = numberReplacer.Replace(s); reThousands.MatchString(s) {
+		s = strings.ReplaceAll(s, ",", "")
+	}
+	return s
+}
+
+// setValue converts text to type of v (string, bool, numbers, time.Time, time.Duration, url.URL,
+// encoding.TextUnmarshaler or pointers to them) and sets it. Empty text sets zero value.
+func setValue(v reflect.Value, s string) error {
+	s = strings.TrimSpace(s)
+	if v.Kind() == reflect.Pointer {
+		if s == "" {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return setValue(v.Elem(), s)
+	}
+	if v.CanAddr() {
+		if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok && v.Type() != typeTime {
+			return u.UnmarshalText([]byte(s))
+		}
+	}
+	if s == "" {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+	switch v.Type() {
+	case typeTime:
+		t, ok := parseTime(s)
+		if !ok {
+			return fmt.Errorf("httpdoc: invalid time %q", s)
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+
+	case typeDuration:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+
+	case typeURL:
+		u, err := url.Parse(s)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(*u))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+
+	case reflect.Bool:
+		switch strings.ToLower(s) {
+		case "1", "true", "yes", "on", "y":
+			v.SetBool(true)
+		case "0", "false", "no", "off", "n":
+			v.SetBool(false)
+		default:
+			return fmt.Errorf("httpdoc: invalid bool %q", s)
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(normNumber(s), 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, err := strconv.ParseUint(normNumber(s), 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(i)
+
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(normNumber(s), v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+
+	case reflect.Interface:
+		v.Set(reflect.ValueOf(s))
+
+	default:
+		return fmt.Errorf("httpdoc: unsupported type %s", v.Type())
+	}
+	return nil
+}